@@ -0,0 +1,264 @@
+package gnuplot
+
+import (
+	"fmt"
+	"math"
+)
+
+// LabelOption is an option of the labels added with AddLabel.
+type LabelOption func(*labelOptions)
+
+type labelOptions struct {
+	align  string
+	rotate float64
+	font   string
+}
+
+// LabelAlign sets the alignment of the label text relative to its position,
+// one of "left" (the default), "center" or "right".
+func LabelAlign(align string) LabelOption {
+	return func(o *labelOptions) {
+		o.align = align
+	}
+}
+
+// LabelRotate rotates the label text by `degrees`.
+func LabelRotate(degrees float64) LabelOption {
+	return func(o *labelOptions) {
+		o.rotate = degrees
+	}
+}
+
+// LabelFont sets the font of the label text. An empty `name` keeps the
+// default font and a zero `size` the default size.
+func LabelFont(name string, size float64) LabelOption {
+	return func(o *labelOptions) {
+		o.font = name
+		if size > 0 {
+			o.font += fmt.Sprintf(",%v", size)
+		}
+	}
+}
+
+// AddLabel places the label `text` at the data coordinates (`x`, `y`).
+// The label is kept for subsequent plots until ClearLabels is called.
+// Example:
+//  err = p.AddLabel("peak", 3, 42,
+//           gnuplot.LabelAlign("center"),
+//           gnuplot.LabelFont("Helvetica", 10))
+func (pltr *Plotter) AddLabel(text string, x, y float64, opts ...LabelOption) error {
+	for _, v := range []float64{x, y} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return &gnuplotError{fmt.Sprintf("invalid label coordinate '%v'", v)}
+		}
+	}
+	o := labelOptions{align: "left"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	switch o.align {
+	case "left", "center", "right":
+	default:
+		return &gnuplotError{fmt.Sprintf("invalid label alignment '%s'", o.align)}
+	}
+
+	tag := len(pltr.labels) + 1
+	line := fmt.Sprintf("set label %d %s at %v,%v %s",
+		tag, quote(text), x, y, o.align)
+	if o.rotate != 0 {
+		line += fmt.Sprintf(" rotate by %v", o.rotate)
+	}
+	if o.font != "" {
+		line += " font " + quote(o.font)
+	}
+
+	err := pltr.Cmd("%s", line)
+	if err != nil {
+		return err
+	}
+	pltr.labels = append(pltr.labels, tag)
+	return nil
+}
+
+// ClearLabels removes all the labels added with AddLabel.
+func (pltr *Plotter) ClearLabels() error {
+	for _, tag := range pltr.labels {
+		err := pltr.Cmd("unset label %d", tag)
+		if err != nil {
+			return err
+		}
+	}
+	pltr.labels = nil
+	return nil
+}
+
+// ArrowOption is an option of the arrows added with AddArrow.
+type ArrowOption func(*arrowOptions)
+
+type arrowOptions struct {
+	head  string
+	color string
+}
+
+// ArrowHead sets the head style of the arrow, one of "head" (the default),
+// "nohead", "backhead" or "heads".
+func ArrowHead(head string) ArrowOption {
+	return func(o *arrowOptions) {
+		o.head = head
+	}
+}
+
+// ArrowColor sets the color of the arrow, eg. "red" or "#ff0000".
+func ArrowColor(color string) ArrowOption {
+	return func(o *arrowOptions) {
+		o.color = color
+	}
+}
+
+// AddArrow draws an arrow from the data coordinates (`x1`, `y1`) to (`x2`,
+// `y2`).
+// The arrow is kept for subsequent plots until ClearArrows is called.
+// Example:
+//  err = p.AddArrow(1, 40, 3, 42,
+//           gnuplot.ArrowHead("heads"),
+//           gnuplot.ArrowColor("red"))
+func (pltr *Plotter) AddArrow(x1, y1, x2, y2 float64, opts ...ArrowOption) error {
+	for _, v := range []float64{x1, y1, x2, y2} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return &gnuplotError{fmt.Sprintf("invalid arrow coordinate '%v'", v)}
+		}
+	}
+	o := arrowOptions{head: "head"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	switch o.head {
+	case "head", "nohead", "backhead", "heads":
+	default:
+		return &gnuplotError{fmt.Sprintf("invalid arrow head '%s'", o.head)}
+	}
+
+	tag := len(pltr.arrows) + 1
+	line := fmt.Sprintf("set arrow %d from %v,%v to %v,%v %s",
+		tag, x1, y1, x2, y2, o.head)
+	if o.color != "" {
+		line += " linecolor rgb " + quote(o.color)
+	}
+
+	err := pltr.Cmd("%s", line)
+	if err != nil {
+		return err
+	}
+	pltr.arrows = append(pltr.arrows, tag)
+	return nil
+}
+
+// ClearArrows removes all the arrows added with AddArrow.
+func (pltr *Plotter) ClearArrows() error {
+	for _, tag := range pltr.arrows {
+		err := pltr.Cmd("unset arrow %d", tag)
+		if err != nil {
+			return err
+		}
+	}
+	pltr.arrows = nil
+	return nil
+}
+
+// ObjectOption is an option of the objects added with AddRectangle.
+type ObjectOption func(*objectOptions)
+
+type objectOptions struct {
+	color   string
+	opacity float64
+}
+
+// ObjectColor sets the fill color of the object, eg. "gray" or "#ffcc00".
+func ObjectColor(color string) ObjectOption {
+	return func(o *objectOptions) {
+		o.color = color
+	}
+}
+
+// ObjectOpacity sets the opacity of the object fill, from 0 (transparent)
+// to 1 (opaque, the default).
+func ObjectOpacity(alpha float64) ObjectOption {
+	return func(o *objectOptions) {
+		o.opacity = alpha
+	}
+}
+
+// AddRectangle draws a filled rectangle from the data coordinates (`x1`,
+// `y1`) to (`x2`, `y2`), behind the plots, eg. to shade a region of interest.
+// The rectangle is kept for subsequent plots until ClearObjects is called.
+// Example:
+//  err = p.AddRectangle(10, -1, 20, 1,
+//           gnuplot.ObjectColor("yellow"),
+//           gnuplot.ObjectOpacity(0.3))
+func (pltr *Plotter) AddRectangle(x1, y1, x2, y2 float64, opts ...ObjectOption) error {
+	for _, v := range []float64{x1, y1, x2, y2} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return &gnuplotError{fmt.Sprintf("invalid rectangle coordinate '%v'", v)}
+		}
+	}
+	o := objectOptions{opacity: 1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.opacity < 0 || o.opacity > 1 {
+		return &gnuplotError{fmt.Sprintf("invalid object opacity '%v'", o.opacity)}
+	}
+
+	tag := len(pltr.objects) + 1
+	line := fmt.Sprintf("set object %d rectangle from %v,%v to %v,%v behind",
+		tag, x1, y1, x2, y2)
+	if o.color != "" {
+		line += " fillcolor rgb " + quote(o.color)
+	}
+	if o.opacity < 1 {
+		line += fmt.Sprintf(" fillstyle transparent solid %v noborder", o.opacity)
+	} else {
+		line += " fillstyle solid noborder"
+	}
+
+	err := pltr.Cmd("%s", line)
+	if err != nil {
+		return err
+	}
+	pltr.objects = append(pltr.objects, tag)
+	return nil
+}
+
+// ClearObjects removes all the objects added with AddRectangle.
+func (pltr *Plotter) ClearObjects() error {
+	for _, tag := range pltr.objects {
+		err := pltr.Cmd("unset object %d", tag)
+		if err != nil {
+			return err
+		}
+	}
+	pltr.objects = nil
+	return nil
+}
+
+// SetKeyTitle changes the title of the key (legend) box.
+// Example:
+//  err = p.SetKeyTitle("Sensors")
+func (pltr *Plotter) SetKeyTitle(title string) error {
+	return pltr.Cmd("set key title %s", quote(title))
+}
+
+// SetKeyMaxRows limits the number of entries per column of the key to `n`,
+// so that the entries of dense plots are laid out in several columns, in
+// plotting order. 0 restores the automatic layout.
+// Example:
+//  err = p.SetKeyMaxRows(4)
+func (pltr *Plotter) SetKeyMaxRows(n int) error {
+	if n < 0 {
+		return &gnuplotError{fmt.Sprintf("invalid key max rows '%v'", n)}
+	}
+	if n == 0 {
+		return pltr.Cmd("set key maxrows auto")
+	}
+	return pltr.Cmd("set key maxrows %d", n)
+}
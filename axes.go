@@ -0,0 +1,321 @@
+package gnuplot
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// axisNames lists the axes accepted by the axis helpers.
+var axisNames = []string{"x", "y", "z", "x2", "y2", "cb"}
+
+// checkAxis returns an error if `axis` is not a valid axis name.
+func checkAxis(axis string) error {
+	for _, a := range axisNames {
+		if a == axis {
+			return nil
+		}
+	}
+	return &gnuplotError{fmt.Sprintf(
+		"invalid axis '%s' (allowed: %v)", axis, axisNames)}
+}
+
+// checkAxes returns an error if `axes` is not a valid pair of axes to plot
+// against.
+func checkAxes(axes string) error {
+	switch axes {
+	case "x1y1", "x1y2", "x2y1", "x2y2":
+		return nil
+	}
+	return &gnuplotError{fmt.Sprintf("invalid axes '%s'", axes)}
+}
+
+// SetAxisFormat changes the format of the tic labels of `axis` (one of "x",
+// "y", "z", "x2", "y2" or "cb").
+// `format` is a printf-like format for numeric data and a strftime-like
+// format for time data.
+// Example:
+//  err = p.SetAxisFormat("y", "%.2f")
+func (pltr *Plotter) SetAxisFormat(axis, format string) error {
+	err := checkAxis(axis)
+	if err != nil {
+		return err
+	}
+	return pltr.Cmd("set format %s %s", axis, quote(format))
+}
+
+// SetTimeFmt changes the strftime-like format used to read time data from
+// data files.
+// Example:
+//  err = p.SetTimeFmt("%Y-%m-%d")
+func (pltr *Plotter) SetTimeFmt(format string) error {
+	if format == "" {
+		return &gnuplotError{"empty time format"}
+	}
+	return pltr.Cmd("set timefmt %s", quote(format))
+}
+
+// setRange changes the range of `axis` to [`min`:`max`].
+func (pltr *Plotter) setRange(axis string, min, max float64) error {
+	for _, v := range []float64{min, max} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return &gnuplotError{fmt.Sprintf("invalid %s range bound '%v'", axis, v)}
+		}
+	}
+	return pltr.Cmd("set %srange [%v:%v]", axis, min, max)
+}
+
+// Range is a range of an axis with optional bounds: a nil bound is left to
+// gnuplot's autoscaling.
+// Example:
+//  lower := 0.0
+//  r := gnuplot.Range{Min: &lower} // [0:*]
+type Range struct {
+	Min, Max *float64
+}
+
+// String returns the gnuplot notation of the range, eg. "[0:*]".
+func (r Range) String() string {
+	bound := func(v *float64) string {
+		if v == nil {
+			return "*"
+		}
+		return fmt.Sprintf("%v", *v)
+	}
+	return "[" + bound(r.Min) + ":" + bound(r.Max) + "]"
+}
+
+// setRangeV changes the range of `axis` to `r`.
+func (pltr *Plotter) setRangeV(axis string, r Range) error {
+	for _, v := range []*float64{r.Min, r.Max} {
+		if v != nil && (math.IsNaN(*v) || math.IsInf(*v, 0)) {
+			return &gnuplotError{fmt.Sprintf("invalid %s range bound '%v'", axis, *v)}
+		}
+	}
+	return pltr.Cmd("set %srange %s", axis, r)
+}
+
+// SetXRange changes the range of the x-axis. A reversed axis is obtained
+// with `min` > `max`.
+// Example:
+//  err = p.SetXRange(0, 10)
+func (pltr *Plotter) SetXRange(min, max float64) error {
+	return pltr.setRange("x", min, max)
+}
+
+// SetXRangeV changes the range of the x-axis to `r`, whose nil bounds are
+// autoscaled, eg. to only fix the lower bound.
+// Example:
+//  lower := 0.0
+//  err = p.SetXRangeV(gnuplot.Range{Min: &lower})
+func (pltr *Plotter) SetXRangeV(r Range) error {
+	return pltr.setRangeV("x", r)
+}
+
+// SetY2Range changes the range of the secondary y-axis. A reversed axis is
+// obtained with `min` > `max`.
+func (pltr *Plotter) SetY2Range(min, max float64) error {
+	return pltr.setRange("y2", min, max)
+}
+
+// SetCBRange changes the range of the color box, ie. the values mapped to
+// the ends of the palette of heatmaps and pm3d surfaces. Fixing it keeps the
+// colors comparable across figures. A reversed palette is obtained with
+// `min` > `max`.
+// Example:
+//  err = p.SetCBRange(0, 100)
+func (pltr *Plotter) SetCBRange(min, max float64) error {
+	return pltr.setRange("cb", min, max)
+}
+
+// SetCBLabel changes the label of the color box.
+func (pltr *Plotter) SetCBLabel(label string) error {
+	return pltr.Cmd("set cblabel %s", quote(label))
+}
+
+// EnableY2Tics turns on the tics of the secondary y-axis, which are off by
+// default.
+func (pltr *Plotter) EnableY2Tics() error {
+	return pltr.Cmd("set y2tics")
+}
+
+// SetX2Range changes the range of the secondary (top) x-axis, eg. to show
+// the x-coordinates in other units. A reversed axis is obtained with
+// `min` > `max`.
+// Data is plotted against it with PlotXYAxes and the "x2y1" or "x2y2" axes.
+func (pltr *Plotter) SetX2Range(min, max float64) error {
+	return pltr.setRange("x2", min, max)
+}
+
+// EnableX2Tics turns on the tics of the secondary x-axis, which are off by
+// default.
+func (pltr *Plotter) EnableX2Tics() error {
+	return pltr.Cmd("set x2tics")
+}
+
+// setTics places the tics of `axis` at `positions`, labeled with `labels` or
+// with the position values if `labels` is nil.
+func (pltr *Plotter) setTics(axis string, positions []float64, labels []string) error {
+	if len(positions) == 0 {
+		return &gnuplotError{fmt.Sprintf("no %s tic positions", axis)}
+	}
+	if labels != nil && len(labels) != len(positions) {
+		return &gnuplotError{fmt.Sprintf(
+			"mismatched lengths: %d tic positions for %d labels",
+			len(positions), len(labels))}
+	}
+	tics := make([]string, 0, len(positions))
+	for i, pos := range positions {
+		if labels != nil {
+			tics = append(tics, fmt.Sprintf("%s %v", quote(labels[i]), pos))
+		} else {
+			tics = append(tics, fmt.Sprintf("%v", pos))
+		}
+	}
+	return pltr.Cmd("set %stics (%s)", axis, strings.Join(tics, ", "))
+}
+
+// setTicsInterval places the tics of `axis` every `incr` from `start` to
+// `end`.
+func (pltr *Plotter) setTicsInterval(axis string, start, incr, end float64) error {
+	if incr == 0 || math.IsNaN(incr) || math.IsInf(incr, 0) {
+		return &gnuplotError{fmt.Sprintf("invalid tic increment '%v'", incr)}
+	}
+	return pltr.Cmd("set %stics %v, %v, %v", axis, start, incr, end)
+}
+
+// SetXTics places the x-axis tics at `positions`, labeled with `labels`.
+// If `labels` is nil, the tics are labeled with their positions.
+// Example:
+//  err = p.SetXTics(
+//           []float64{0, math.Pi, 2 * math.Pi},
+//           []string{"0", "π", "2π"})
+func (pltr *Plotter) SetXTics(positions []float64, labels []string) error {
+	return pltr.setTics("x", positions, labels)
+}
+
+// SetYTics places the y-axis tics at `positions`, see SetXTics.
+func (pltr *Plotter) SetYTics(positions []float64, labels []string) error {
+	return pltr.setTics("y", positions, labels)
+}
+
+// SetZTics places the z-axis tics at `positions`, see SetXTics.
+func (pltr *Plotter) SetZTics(positions []float64, labels []string) error {
+	return pltr.setTics("z", positions, labels)
+}
+
+// SetXTicsInterval places the x-axis tics every `incr` from `start` to
+// `end`.
+// Example:
+//  err = p.SetXTicsInterval(0, 0.5, 10)
+func (pltr *Plotter) SetXTicsInterval(start, incr, end float64) error {
+	return pltr.setTicsInterval("x", start, incr, end)
+}
+
+// SetYTicsInterval places the y-axis tics every `incr` from `start` to
+// `end`.
+func (pltr *Plotter) SetYTicsInterval(start, incr, end float64) error {
+	return pltr.setTicsInterval("y", start, incr, end)
+}
+
+// SetZTicsInterval places the z-axis tics every `incr` from `start` to
+// `end`.
+func (pltr *Plotter) SetZTicsInterval(start, incr, end float64) error {
+	return pltr.setTicsInterval("z", start, incr, end)
+}
+
+// SetAutoscale turns autoscaling back on for `axes` (among "x", "y", "z",
+// "x2", "y2" and "cb"), eg. after setting their range. All the axes are
+// autoscaled if none is given.
+// Example:
+//  err = p.SetAutoscale("x", "y2")
+func (pltr *Plotter) SetAutoscale(axes ...string) error {
+	if len(axes) == 0 {
+		return pltr.Cmd("set autoscale")
+	}
+	for _, axis := range axes {
+		err := checkAxis(axis)
+		if err != nil {
+			return err
+		}
+	}
+	cmds := make([]string, 0, len(axes))
+	for _, axis := range axes {
+		cmds = append(cmds, "set autoscale "+axis)
+	}
+	return pltr.cmds(cmds...)
+}
+
+// Sides of the plot border, to be combined for SetBorder.
+const (
+	BorderBottom = 1
+	BorderLeft   = 2
+	BorderTop    = 4
+	BorderRight  = 8
+)
+
+// SetBorder draws the plot border on the `sides` only, a bitmask of:
+//    BorderBottom (1),
+//    BorderLeft   (2),
+//    BorderTop    (4),
+//    BorderRight  (8)
+// The higher bits, up to 2048, select the edges of the 3-d plots, see the
+// gnuplot documentation of 'set border'.
+// Example:
+//  err = p.SetBorder(gnuplot.BorderBottom | gnuplot.BorderLeft)
+func (pltr *Plotter) SetBorder(sides int) error {
+	if sides <= 0 || sides >= 4096 {
+		return &gnuplotError{fmt.Sprintf("invalid border sides '%v'", sides)}
+	}
+	return pltr.Cmd("set border %d", sides)
+}
+
+// UnsetBorder removes the plot border.
+func (pltr *Plotter) UnsetBorder() error {
+	return pltr.Cmd("unset border")
+}
+
+// SetXTicsRotate rotates the x-axis tic labels by `degrees`
+// (counterclockwise, within [-360, 360]), eg. to keep long labels from
+// overlapping.
+// Example:
+//  err = p.SetXTicsRotate(45)
+func (pltr *Plotter) SetXTicsRotate(degrees float64) error {
+	if !(degrees >= -360 && degrees <= 360) {
+		return &gnuplotError{fmt.Sprintf("invalid tics rotation '%v'", degrees)}
+	}
+	return pltr.Cmd("set xtics rotate by %v", degrees)
+}
+
+// SetTicsScale changes the length of the major and minor tic marks of all
+// the axes, relative to their default length.
+// Example:
+//  err = p.SetTicsScale(2, 1)
+func (pltr *Plotter) SetTicsScale(major, minor float64) error {
+	if !(major >= 0) || !(minor >= 0) {
+		return &gnuplotError{fmt.Sprintf("invalid tics scale '%v,%v'", major, minor)}
+	}
+	return pltr.Cmd("set tics scale %v, %v", major, minor)
+}
+
+// SetView changes the viewing angle of the 3-d plots: the view is rotated
+// by `rotX` degrees (within [0, 180]) around the x-axis of the screen, then
+// by `rotZ` degrees (within [0, 360]) around the z-axis of the plot. The
+// gnuplot default is 60, 30.
+// Example:
+//  err = p.SetView(70, 120)
+func (pltr *Plotter) SetView(rotX, rotZ float64) error {
+	if !(rotX >= 0 && rotX <= 180) {
+		return &gnuplotError{fmt.Sprintf("invalid x rotation '%v'", rotX)}
+	}
+	if !(rotZ >= 0 && rotZ <= 360) {
+		return &gnuplotError{fmt.Sprintf("invalid z rotation '%v'", rotZ)}
+	}
+	return pltr.Cmd("set view %v,%v", rotX, rotZ)
+}
+
+// SetViewMap changes the view of the 3-d plots to a top-down view, eg. to
+// draw a surface as a flat map.
+func (pltr *Plotter) SetViewMap() error {
+	return pltr.Cmd("set view map")
+}
@@ -0,0 +1,1077 @@
+package gnuplot
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PlotHeatmap will create a heatmap of `matrix` with `title` as the plot
+// title.
+// The column index of an element is used as its x-coordinate and the row
+// index as its y-coordinate. The matrix must not be empty and all its rows
+// must have the same length.
+// The plot is done with 'set view map' and 'splot ... with image', the view
+// setting is kept for subsequent plots. The colormap can be picked with
+// SetPalette or SetPaletteDefined.
+// Example:
+//  err = p.PlotHeatmap(
+//           [][]float64{
+//             {1, 2, 3},
+//             {4, 5, 6}},
+//           "my title")
+func (pltr *Plotter) PlotHeatmap(matrix [][]float64, title string) error {
+	if len(matrix) == 0 || len(matrix[0]) == 0 {
+		return &gnuplotError{"empty matrix"}
+	}
+	ncols := len(matrix[0])
+	for i, row := range matrix {
+		if len(row) != ncols {
+			return &gnuplotError{fmt.Sprintf(
+				"non-rectangular matrix: row %d has %d columns, expected %d",
+				i, len(row), ncols)}
+		}
+	}
+
+	fname, err := pltr.writeTmpfile(func(w *dataWriter) {
+		for _, row := range matrix {
+			for j, v := range row {
+				if j > 0 {
+					w.WriteString(" ")
+				}
+				w.Printf("%v", v)
+			}
+			w.WriteString("\n")
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = pltr.Cmd("set view map")
+	if err != nil {
+		return err
+	}
+	return pltr.plotData("splot", fname, "matrix", title, "image")
+}
+
+// dataLabel formats `label` as a quoted string column of a data file.
+// Double quotes can't be escaped in data files so they are replaced by
+// single quotes, and line breaks by spaces.
+func dataLabel(label string) string {
+	r := strings.NewReplacer("\"", "'", "\r\n", " ", "\n", " ", "\r", " ")
+	return "\"" + r.Replace(label) + "\""
+}
+
+// PlotBars will create a bar chart with one bar per category, using `labels`
+// as the category names and `values` as the bar heights, and `title` as the
+// plot title.
+// `labels` and `values` must have the same length.
+// The plot is done with the histogram data style and solid fill, both
+// settings are kept for subsequent plots.
+// Example:
+//  err = p.PlotBars(
+//           []string{"foo", "bar", "baz"},
+//           []float64{10, 20, 30},
+//           "my title")
+func (pltr *Plotter) PlotBars(labels []string, values []float64, title string) error {
+	if len(labels) != len(values) {
+		return &gnuplotError{fmt.Sprintf(
+			"mismatched lengths: %d labels for %d values",
+			len(labels), len(values))}
+	}
+	if len(values) == 0 {
+		return errNoData
+	}
+
+	fname, err := pltr.writeTmpfile(func(w *dataWriter) {
+		for i, v := range values {
+			w.Printf("%d %s %v\n", i, dataLabel(labels[i]), v)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = pltr.cmds(
+		"set style data histogram",
+		"set style fill solid")
+	if err != nil {
+		return err
+	}
+	return pltr.plotData("plot", fname, "using 3:xtic(2)", title, "histograms")
+}
+
+// PlotBarsGrouped will create a bar chart with several series of bars per
+// category, using `labels` as the category names and `series` as the bar
+// heights of each series, keyed by series title.
+// Every series must have the same length as `labels`. Series are drawn in
+// the sorted order of their titles.
+// If `stacked` is true, the bars of a category are stacked on top of each
+// other, otherwise they are drawn side by side.
+// Example:
+//  err = p.PlotBarsGrouped(
+//           []string{"foo", "bar", "baz"},
+//           map[string][]float64{
+//             "2016": {10, 20, 30},
+//             "2017": {15, 25, 35}},
+//           false)
+func (pltr *Plotter) PlotBarsGrouped(labels []string, series map[string][]float64, stacked bool) error {
+	if len(series) == 0 {
+		return &gnuplotError{"no series to plot"}
+	}
+	if len(labels) == 0 {
+		return errNoData
+	}
+	names := make([]string, 0, len(series))
+	for name, values := range series {
+		if len(values) != len(labels) {
+			return &gnuplotError{fmt.Sprintf(
+				"mismatched lengths: series '%s' has %d values for %d labels",
+				name, len(values), len(labels))}
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fname, err := pltr.writeTmpfile(func(w *dataWriter) {
+		for i, label := range labels {
+			w.Printf("%d %s", i, dataLabel(label))
+			for _, name := range names {
+				w.Printf(" %v", series[name][i])
+			}
+			w.WriteString("\n")
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	histogram := "clustered"
+	if stacked {
+		histogram = "rowstacked"
+	}
+	err = pltr.cmds(
+		"set style data histogram",
+		"set style histogram "+histogram,
+		"set style fill solid")
+	if err != nil {
+		return err
+	}
+
+	cmd := "plot"
+	if pltr.nplots > 0 {
+		cmd = "replot"
+	}
+	plots := make([]string, 0, len(names))
+	for i, name := range names {
+		if i == 0 {
+			plots = append(plots, fmt.Sprintf(
+				"%s using 3:xtic(2) title %s with histograms",
+				quote(fname), quote(name)))
+		} else {
+			plots = append(plots, fmt.Sprintf(
+				"\"\" using %d title %s with histograms", i+3, quote(name)))
+		}
+	}
+	pltr.nplots += len(names)
+	return pltr.Cmd("%s %s", cmd, strings.Join(plots, ", "))
+}
+
+// PlotBoxplot will create a box-and-whisker plot with one box per group of
+// samples in `groups`, keyed by group name.
+// Groups may have different sizes, they are drawn in the sorted order of
+// their names and the x-axis tics are labeled with these names.
+// Boxplots require gnuplot >= 4.6.
+// Example:
+//  err = p.PlotBoxplot(
+//           map[string][]float64{
+//             "foo": {1, 2, 3, 4, 10},
+//             "bar": {2, 3, 3, 5}})
+func (pltr *Plotter) PlotBoxplot(groups map[string][]float64) error {
+	if len(groups) == 0 {
+		return &gnuplotError{"no groups to plot"}
+	}
+	names := make([]string, 0, len(groups))
+	for name, samples := range groups {
+		if len(samples) == 0 {
+			return &gnuplotError{fmt.Sprintf("empty group '%s'", name)}
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fname, err := pltr.writeTmpfile(func(w *dataWriter) {
+		// One data block per group, so that each of them can be selected with
+		// the 'index' modifier.
+		for i, name := range names {
+			if i > 0 {
+				w.WriteString("\n\n")
+			}
+			for _, v := range groups[name] {
+				w.Printf("%d %v\n", i+1, v)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	tics := make([]string, 0, len(names))
+	for i, name := range names {
+		tics = append(tics, fmt.Sprintf("%s %d", quote(name), i+1))
+	}
+	err = pltr.cmds(
+		"set style boxplot outliers pointtype 7",
+		"set style fill solid 0.25 border",
+		fmt.Sprintf("set xtics (%s)", strings.Join(tics, ", ")))
+	if err != nil {
+		return err
+	}
+
+	cmd := "plot"
+	if pltr.nplots > 0 {
+		cmd = "replot"
+	}
+	plots := make([]string, 0, len(names))
+	for i := range names {
+		plots = append(plots, fmt.Sprintf(
+			"%s index %d using 1:2 notitle with boxplot", quote(fname), i))
+	}
+	pltr.nplots += len(names)
+	return pltr.Cmd("%s %s", cmd, strings.Join(plots, ", "))
+}
+
+// PlotCandlestick will create a candlestick (OHLC) chart using `t` as the
+// x-coordinates, `open`, `high`, `low` and `close` as the prices and `title`
+// as the plot title.
+// All five slices must have the same length.
+// Example:
+//  err = p.PlotCandlestick(
+//           []float64{1, 2, 3},
+//           []float64{10, 12, 11},
+//           []float64{13, 14, 12},
+//           []float64{9, 11, 8},
+//           []float64{12, 11, 9},
+//           "my title")
+func (pltr *Plotter) PlotCandlestick(t, open, high, low, close []float64, title string) error {
+	x := make([]string, len(t))
+	for i, v := range t {
+		x[i] = fmt.Sprintf("%v", v)
+	}
+	return pltr.plotCandlestick(x, open, high, low, close, title)
+}
+
+// PlotCandlestickTime is the same as PlotCandlestick but with timestamps as
+// the x-coordinates. The x-axis is switched to time data and the
+// timestamps are written in UTC.
+// Example:
+//  err = p.PlotCandlestickTime(
+//           []time.Time{day1, day2, day3},
+//           []float64{10, 12, 11},
+//           []float64{13, 14, 12},
+//           []float64{9, 11, 8},
+//           []float64{12, 11, 9},
+//           "my title")
+func (pltr *Plotter) PlotCandlestickTime(t []time.Time, open, high, low, close []float64, title string) error {
+	x := make([]string, len(t))
+	for i, v := range t {
+		x[i] = formatTime(v, time.UTC)
+	}
+	err := checkCandlestick(len(x), open, high, low, close)
+	if err != nil {
+		return err
+	}
+	err = pltr.setTimeAxis("x", defaultTimeDisplayFormat)
+	if err != nil {
+		return err
+	}
+	return pltr.plotCandlestick(x, open, high, low, close, title)
+}
+
+// checkCandlestick checks that the prices match the `npoints` dates of a
+// candlestick plot.
+func checkCandlestick(npoints int, open, high, low, close []float64) error {
+	for _, s := range [][]float64{open, high, low, close} {
+		if len(s) != npoints {
+			return &gnuplotError{fmt.Sprintf(
+				"mismatched lengths: %d dates for %d open, %d high, %d low and %d close prices",
+				npoints, len(open), len(high), len(low), len(close))}
+		}
+	}
+	if npoints == 0 {
+		return errNoData
+	}
+	return nil
+}
+
+func (pltr *Plotter) plotCandlestick(x []string, open, high, low, close []float64, title string) error {
+	err := checkCandlestick(len(x), open, high, low, close)
+	if err != nil {
+		return err
+	}
+
+	fname, err := pltr.writeTmpfile(func(w *dataWriter) {
+		for i := range x {
+			w.Printf("%s %v %v %v %v\n",
+				x[i], open[i], low[i], high[i], close[i])
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return pltr.plotData("plot", fname, "using 1:2:3:4:5", title, "candlesticks")
+}
+
+// FillOption is an option of the filled plots.
+type FillOption func(*fillOptions)
+
+type fillOptions struct {
+	color   string
+	opacity float64
+}
+
+// FillColor sets the fill color, eg. "red" or "#ff0000".
+func FillColor(color string) FillOption {
+	return func(o *fillOptions) {
+		o.color = color
+	}
+}
+
+// FillOpacity sets the opacity of the fill, from 0 (transparent) to 1
+// (opaque, the default).
+func FillOpacity(opacity float64) FillOption {
+	return func(o *fillOptions) {
+		o.opacity = opacity
+	}
+}
+
+// PlotFillBetween will create a 2-d plot filling the area between the curves
+// (`x`, `yLow`) and (`x`, `yHigh`), with `title` as the plot title.
+// All three slices must have the same length.
+// Example:
+//  err = p.PlotFillBetween(
+//           []float64{0, 1, 2},
+//           []float64{0, 1, 2},
+//           []float64{1, 3, 5},
+//           "my title",
+//           gnuplot.FillColor("blue"),
+//           gnuplot.FillOpacity(0.3))
+func (pltr *Plotter) PlotFillBetween(x, yLow, yHigh []float64, title string, opts ...FillOption) error {
+	if len(yLow) != len(x) || len(yHigh) != len(x) {
+		return &gnuplotError{fmt.Sprintf(
+			"mismatched lengths: %d x for %d low and %d high values",
+			len(x), len(yLow), len(yHigh))}
+	}
+	o := fillOptions{opacity: 1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.opacity < 0 || o.opacity > 1 {
+		return &gnuplotError{fmt.Sprintf("invalid fill opacity '%v'", o.opacity)}
+	}
+	if len(x) == 0 {
+		return errNoData
+	}
+
+	fname, err := pltr.writeTmpfile(func(w *dataWriter) {
+		for i := range x {
+			w.Printf("%v %v %v\n", x[i], yLow[i], yHigh[i])
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	with := "filledcurves"
+	if o.color != "" {
+		with += " fillcolor rgb " + quote(o.color)
+	}
+	if o.opacity < 1 {
+		with += fmt.Sprintf(" fillstyle transparent solid %v", o.opacity)
+	} else {
+		with += " fillstyle solid"
+	}
+	return pltr.plotData("plot", fname, "using 1:2:3", title, with)
+}
+
+// PlotBubble will create a bubble chart using `x` and `y` as the coordinates
+// of the bubbles, `size` as their relative size and `title` as the plot
+// title.
+// All three slices must have the same length.
+// Example:
+//  err = p.PlotBubble(
+//           []float64{0, 1, 2},
+//           []float64{0, 1, 4},
+//           []float64{1, 2, 3},
+//           "my title")
+func (pltr *Plotter) PlotBubble(x, y, size []float64, title string) error {
+	return pltr.plotBubble(x, y, size, nil, title)
+}
+
+// PlotBubbleColor is the same as PlotBubble but also colors each bubble
+// according to `color`, mapped through the current palette.
+// All four slices must have the same length.
+// Example:
+//  err = p.PlotBubbleColor(
+//           []float64{0, 1, 2},
+//           []float64{0, 1, 4},
+//           []float64{1, 2, 3},
+//           []float64{0.1, 0.5, 0.9},
+//           "my title")
+func (pltr *Plotter) PlotBubbleColor(x, y, size, color []float64, title string) error {
+	if color == nil {
+		return &gnuplotError{"nil color values"}
+	}
+	return pltr.plotBubble(x, y, size, color, title)
+}
+
+func (pltr *Plotter) plotBubble(x, y, size, color []float64, title string) error {
+	if len(y) != len(x) || len(size) != len(x) ||
+		(color != nil && len(color) != len(x)) {
+		return &gnuplotError{fmt.Sprintf(
+			"mismatched lengths: %d x, %d y, %d size and %d color values",
+			len(x), len(y), len(size), len(color))}
+	}
+	if len(x) == 0 {
+		return errNoData
+	}
+
+	fname, err := pltr.writeTmpfile(func(w *dataWriter) {
+		for i := range x {
+			if color != nil {
+				w.Printf("%v %v %v %v\n", x[i], y[i], size[i], color[i])
+			} else {
+				w.Printf("%v %v %v\n", x[i], y[i], size[i])
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if color != nil {
+		return pltr.plotData("plot", fname, "using 1:2:3:4", title,
+			"points pointtype 7 pointsize variable linecolor palette")
+	}
+	return pltr.plotData("plot", fname, "using 1:2:3", title,
+		"points pointtype 7 pointsize variable")
+}
+
+// SetBoxWidth changes the width of the boxes drawn by the boxes and
+// histogram styles.
+// `mode` is either "absolute", for a width in x-axis units, or "relative",
+// for a width relative to the default width.
+// Example:
+//  err = p.SetBoxWidth(0.8, "relative")
+func (pltr *Plotter) SetBoxWidth(width float64, mode string) error {
+	if !(width > 0) {
+		return &gnuplotError{fmt.Sprintf("invalid box width '%v'", width)}
+	}
+	switch mode {
+	case "absolute", "relative":
+	default:
+		return &gnuplotError{fmt.Sprintf("invalid box width mode '%s'", mode)}
+	}
+	return pltr.Cmd("set boxwidth %v %s", width, mode)
+}
+
+// SetFillStyle changes how boxes, bars and filled curves are filled.
+// If `solid` is true they are filled with their color at the `density`
+// going from 0 (empty) to 1 (full color), otherwise they are left empty and
+// `density` is ignored. `border` controls whether their border is drawn.
+// Example:
+//  err = p.SetFillStyle(true, 0.5, false)
+func (pltr *Plotter) SetFillStyle(solid bool, density float64, border bool) error {
+	line := "set style fill empty"
+	if solid {
+		if !(density >= 0 && density <= 1) {
+			return &gnuplotError{fmt.Sprintf("invalid fill density '%v'", density)}
+		}
+		line = fmt.Sprintf("set style fill solid %v", density)
+	}
+	if border {
+		line += " border"
+	} else {
+		line += " noborder"
+	}
+	return pltr.Cmd("%s", line)
+}
+
+// VectorOption is an option of the vector field plots.
+type VectorOption func(*vectorOptions)
+
+type vectorOptions struct {
+	scale    float64
+	headSize float64
+}
+
+// VectorScale multiplies the length of all the vectors by `scale`, which
+// defaults to 1.
+func VectorScale(scale float64) VectorOption {
+	return func(o *vectorOptions) {
+		o.scale = scale
+	}
+}
+
+// VectorHeadSize sets the length of the arrow heads, in x-axis units. A
+// zero size (the default) keeps the gnuplot default.
+func VectorHeadSize(size float64) VectorOption {
+	return func(o *vectorOptions) {
+		o.headSize = size
+	}
+}
+
+// PlotVectorField will create a 2-d plot of vectors starting at the points
+// (`x`, `y`) and spanning (`dx`, `dy`), with `title` as the plot title.
+// All four slices must have the same length.
+// Example:
+//  err = p.PlotVectorField(
+//           []float64{0, 1, 2},
+//           []float64{0, 0, 0},
+//           []float64{1, 0, -1},
+//           []float64{0, 1, 0},
+//           "my title",
+//           gnuplot.VectorScale(0.5))
+func (pltr *Plotter) PlotVectorField(x, y, dx, dy []float64, title string, opts ...VectorOption) error {
+	if len(y) != len(x) || len(dx) != len(x) || len(dy) != len(x) {
+		return &gnuplotError{fmt.Sprintf(
+			"mismatched lengths: %d x, %d y, %d dx and %d dy values",
+			len(x), len(y), len(dx), len(dy))}
+	}
+	o := vectorOptions{scale: 1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.headSize < 0 {
+		return &gnuplotError{fmt.Sprintf("invalid vector head size '%v'", o.headSize)}
+	}
+	if len(x) == 0 {
+		return errNoData
+	}
+
+	fname, err := pltr.writeTmpfile(func(w *dataWriter) {
+		for i := range x {
+			w.Printf("%v %v %v %v\n", x[i], y[i], o.scale*dx[i], o.scale*dy[i])
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	with := "vectors head filled"
+	if o.headSize > 0 {
+		with += fmt.Sprintf(" size %v,20", o.headSize)
+	}
+	return pltr.plotData("plot", fname, "using 1:2:3:4", title, with)
+}
+
+// PlotContour will create a contour plot of `fct` with `levels` contour
+// levels, sampling `fct` over the grid of points (`xs[i]`, `ys[j]`).
+// The plot is seen from above ('set view map') with the contour lines only
+// ('unset surface'), these settings are kept for subsequent plots.
+// Example:
+//  fct := func(x, y float64) float64 { return x*x + y*y }
+//  err = p.PlotContour(
+//           []float64{-2, -1, 0, 1, 2},
+//           []float64{-2, -1, 0, 1, 2},
+//           fct,
+//           5)
+func (pltr *Plotter) PlotContour(xs, ys []float64, fct func(x, y float64) float64, levels int) error {
+	if fct == nil {
+		return &gnuplotError{"nil function"}
+	}
+	if levels <= 0 {
+		return &gnuplotError{fmt.Sprintf("invalid number of contour levels '%v'", levels)}
+	}
+	if len(xs) == 0 || len(ys) == 0 {
+		return &gnuplotError{"empty grid"}
+	}
+
+	// One scan line per y-coordinate, separated by blank lines.
+	fname, err := pltr.writeTmpfile(func(w *dataWriter) {
+		for j, y := range ys {
+			if j > 0 {
+				w.WriteString("\n")
+			}
+			for _, x := range xs {
+				w.Printf("%v %v %v\n", x, y, fct(x, y))
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = pltr.cmds(
+		"set contour base",
+		"unset surface",
+		"set view map",
+		fmt.Sprintf("set cntrparam levels %d", levels))
+	if err != nil {
+		return err
+	}
+	return pltr.plotData("splot", fname, "", "", "lines")
+}
+
+// PolarOption is an option of the polar plots.
+type PolarOption func(*polarOptions)
+
+type polarOptions struct {
+	degrees bool
+}
+
+// PolarDegrees makes the angles of a polar plot be read in degrees rather
+// than in radians.
+func PolarDegrees() PolarOption {
+	return func(o *polarOptions) {
+		o.degrees = true
+	}
+}
+
+// PlotPolar will create a polar plot using `theta` as the angles and `r` as
+// the matching radii, with `title` as the plot title.
+// Both slices must have the same length.
+// Note that polar mode ('set polar') changes how gnuplot interprets all
+// subsequent plots: it stays on until ResetPlot or ResetAll is called.
+// The angle unit ('set angles') also applies to the trigonometric functions
+// of gnuplot and is kept after ResetPlot.
+// Example:
+//  err = p.PlotPolar(
+//           []float64{0, 90, 180, 270},
+//           []float64{1, 2, 1, 2},
+//           "my title",
+//           gnuplot.PolarDegrees())
+func (pltr *Plotter) PlotPolar(theta, r []float64, title string, opts ...PolarOption) error {
+	if len(theta) != len(r) {
+		return &gnuplotError{fmt.Sprintf(
+			"mismatched lengths: %d angles for %d radii", len(theta), len(r))}
+	}
+	o := polarOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if len(theta) == 0 {
+		return errNoData
+	}
+
+	fname, err := pltr.writeTmpfile(func(w *dataWriter) {
+		for i := range theta {
+			w.Printf("%v %v\n", theta[i], r[i])
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	angles := "radians"
+	if o.degrees {
+		angles = "degrees"
+	}
+	err = pltr.cmds("set polar", "set angles "+angles)
+	if err != nil {
+		return err
+	}
+	pltr.polar = true
+	return pltr.plotData("plot", fname, "using 1:2", title, pltr.style)
+}
+
+// PlotParametric will create a 2-d plot of the parametric curve
+// (`fx(t[i])`, `fy(t[i])`) with `title` as the plot title.
+// Note that parametric mode ('set parametric') is left on afterwards: it
+// changes how gnuplot interprets subsequent function plots, which then
+// expect pairs of expressions. Use 'unset parametric' or ResetAll to leave
+// it.
+// Example:
+//  err = p.PlotParametric(
+//           []float64{0, 0.1, 0.2, 0.3},
+//           math.Cos,
+//           math.Sin,
+//           "my title")
+func (pltr *Plotter) PlotParametric(t []float64, fx, fy func(t float64) float64, title string) error {
+	if fx == nil || fy == nil {
+		return &gnuplotError{"nil parametric function"}
+	}
+	if len(t) == 0 {
+		return errNoData
+	}
+
+	fname, err := pltr.writeTmpfile(func(w *dataWriter) {
+		for _, v := range t {
+			w.Printf("%v %v\n", fx(v), fy(v))
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = pltr.Cmd("set parametric")
+	if err != nil {
+		return err
+	}
+	return pltr.plotData("plot", fname, "", title, pltr.style)
+}
+
+// PlotParametricExpr is the same as PlotParametric but with the gnuplot
+// expressions `xExpr` and `yExpr` of the parameter 't', which are sampled by
+// gnuplot itself over the current t-range.
+// Example:
+//  err = p.PlotParametricExpr("cos(t)", "sin(2*t)", "my title")
+func (pltr *Plotter) PlotParametricExpr(xExpr, yExpr, title string) error {
+	if strings.TrimSpace(xExpr) == "" || strings.TrimSpace(yExpr) == "" {
+		return &gnuplotError{"empty expression"}
+	}
+	err := pltr.Cmd("set parametric")
+	if err != nil {
+		return err
+	}
+	return pltr.PlotFuncExpr(xExpr+", "+yExpr, title)
+}
+
+// PlotStem will create a stem plot of the discrete signal (`x`, `y`), with
+// `title` as the plot title: a vertical stem topped by a marker goes from
+// the horizontal line at `baseline` to each point.
+// Both slices must have the same length.
+// The stems are drawn as vectors rather than with the impulses style, whose
+// impulses always start from 0.
+// Example:
+//  err = p.PlotStem(
+//           []float64{0, 1, 2, 3},
+//           []float64{1, 3, 2, 0.5},
+//           0.5,
+//           "my title")
+func (pltr *Plotter) PlotStem(x, y []float64, baseline float64, title string) error {
+	if len(x) != len(y) {
+		return &gnuplotError{fmt.Sprintf(
+			"mismatched lengths: %d x for %d y values", len(x), len(y))}
+	}
+	if math.IsNaN(baseline) || math.IsInf(baseline, 0) {
+		return &gnuplotError{fmt.Sprintf("invalid baseline '%v'", baseline)}
+	}
+	if len(x) == 0 {
+		return errNoData
+	}
+
+	fname, err := pltr.writeTmpfile(func(w *dataWriter) {
+		for i := range x {
+			w.Printf("%v %v\n", x[i], y[i])
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	cmd := "plot"
+	if pltr.nplots > 0 {
+		cmd = "replot"
+	}
+	plots := []string{
+		plotElement(fname, fmt.Sprintf("using 1:(%v):(0):($2-(%v))", baseline, baseline),
+			title, "vectors nohead"),
+		fmt.Sprintf("%s using 1:2 notitle with points pointtype 7", quote(fname)),
+		fmt.Sprintf("%v notitle with lines", baseline),
+	}
+	pltr.nplots += len(plots)
+	return pltr.Cmd("%s %s", cmd, strings.Join(plots, ", "))
+}
+
+// PlotStep will create a step plot of the signal (`x`, `y`), holding the
+// values between the samples, with `title` as the plot title.
+// `mode` sets where the steps happen:
+//    "steps": the value holds until the next sample (post),
+//    "fsteps": the value holds from the previous sample (pre),
+//    "histeps": the steps are centered between the samples.
+// Both slices must have the same length.
+// Example:
+//  err = p.PlotStep(
+//           []float64{0, 1, 2, 3},
+//           []float64{1, 3, 2, 0.5},
+//           "fsteps",
+//           "my title")
+func (pltr *Plotter) PlotStep(x, y []float64, mode, title string) error {
+	switch mode {
+	case "steps", "fsteps", "histeps":
+	default:
+		return &gnuplotError{fmt.Sprintf("invalid step mode '%s'", mode)}
+	}
+	if len(x) != len(y) {
+		return &gnuplotError{fmt.Sprintf(
+			"mismatched lengths: %d x for %d y values", len(x), len(y))}
+	}
+	if len(x) == 0 {
+		return errNoData
+	}
+
+	fname, err := pltr.writeTmpfile(func(w *dataWriter) {
+		step := pltr.stride(len(x))
+		for i := 0; i < len(x); i += step {
+			w.Printf("%v %v\n", x[i], y[i])
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return pltr.plotData(pltr.plotcmd, fname, "", title, mode)
+}
+
+// PlotLabels will create a plot of the texts `labels` placed at the points
+// (`x`, `y`), eg. to annotate each point with its value or name, with
+// `title` as the plot title.
+// The three slices must have the same length.
+// Example:
+//  err = p.PlotLabels(
+//           []float64{1, 2, 3},
+//           []float64{4, 1, 3},
+//           []string{"A", "B", "C"},
+//           "my title")
+func (pltr *Plotter) PlotLabels(x, y []float64, labels []string, title string) error {
+	if len(x) != len(y) || len(x) != len(labels) {
+		return &gnuplotError{fmt.Sprintf(
+			"mismatched lengths: %d x for %d y values and %d labels",
+			len(x), len(y), len(labels))}
+	}
+	if len(x) == 0 {
+		return errNoData
+	}
+
+	fname, err := pltr.writeTmpfile(func(w *dataWriter) {
+		for i := range x {
+			w.Printf("%v %v %s\n", x[i], y[i], dataLabel(labels[i]))
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return pltr.plotData("plot", fname, "using 1:2:3", title, "labels")
+}
+
+// polyFit returns the coefficients, by increasing power, of the polynomial
+// of degree `degree` fitting the points (`x`, `y`) in the least-squares
+// sense, solving the normal equations by Gaussian elimination.
+func polyFit(x, y []float64, degree int) ([]float64, error) {
+	n := degree + 1
+	// a is the augmented matrix of the normal equations.
+	a := make([][]float64, n)
+	for i := range a {
+		a[i] = make([]float64, n+1)
+	}
+	for k := range x {
+		pow := make([]float64, 2*n-1)
+		pow[0] = 1
+		for i := 1; i < len(pow); i++ {
+			pow[i] = pow[i-1] * x[k]
+		}
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				a[i][j] += pow[i+j]
+			}
+			a[i][n] += pow[i] * y[k]
+		}
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for i := col + 1; i < n; i++ {
+			if math.Abs(a[i][col]) > math.Abs(a[pivot][col]) {
+				pivot = i
+			}
+		}
+		if a[pivot][col] == 0 {
+			return nil, &gnuplotError{"singular fit: not enough distinct x values"}
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		for i := col + 1; i < n; i++ {
+			f := a[i][col] / a[col][col]
+			for j := col; j <= n; j++ {
+				a[i][j] -= f * a[col][j]
+			}
+		}
+	}
+
+	coefs := make([]float64, n)
+	for i := n - 1; i >= 0; i-- {
+		sum := a[i][n]
+		for j := i + 1; j < n; j++ {
+			sum -= a[i][j] * coefs[j]
+		}
+		coefs[i] = sum / a[i][i]
+	}
+	return coefs, nil
+}
+
+// PlotXYFit will create a 2-d plot of the points (`x`, `y`), with `title` as
+// the plot title, overlaid with the polynomial of degree `degree` fitting
+// them in the least-squares sense, eg. 1 for a linear regression.
+// It returns the coefficients of the polynomial, by increasing power.
+// Both slices must have the same length, of at least `degree`+1 points.
+// Example:
+//  coefs, err := p.PlotXYFit(
+//           []float64{0, 1, 2, 3},
+//           []float64{0.1, 0.9, 2.1, 2.9},
+//           1,
+//           "my title")
+func (pltr *Plotter) PlotXYFit(x, y []float64, degree int, title string) ([]float64, error) {
+	if degree < 0 {
+		return nil, &gnuplotError{fmt.Sprintf("invalid fit degree '%v'", degree)}
+	}
+	if len(x) != len(y) {
+		return nil, &gnuplotError{fmt.Sprintf(
+			"mismatched lengths: %d x for %d y values", len(x), len(y))}
+	}
+	if len(x) <= degree {
+		return nil, &gnuplotError{fmt.Sprintf(
+			"not enough points: %d points for a fit of degree %d", len(x), degree)}
+	}
+	coefs, err := polyFit(x, y, degree)
+	if err != nil {
+		return nil, err
+	}
+
+	fname, err := pltr.writeTmpfile(func(w *dataWriter) {
+		step := pltr.stride(len(x))
+		for i := 0; i < len(x); i += step {
+			w.Printf("%v %v\n", x[i], y[i])
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	terms := make([]string, len(coefs))
+	for i, c := range coefs {
+		switch i {
+		case 0:
+			terms[i] = fmt.Sprintf("(%v)", c)
+		case 1:
+			terms[i] = fmt.Sprintf("(%v)*x", c)
+		default:
+			terms[i] = fmt.Sprintf("(%v)*x**%d", c, i)
+		}
+	}
+	fitTitle := "notitle"
+	if title != "" {
+		fitTitle = "title " + quote(title+" fit")
+	}
+
+	cmd := "plot"
+	if pltr.nplots > 0 {
+		cmd = "replot"
+	}
+	plots := []string{
+		plotElement(fname, "", title, "points"),
+		fmt.Sprintf("%s %s with lines", strings.Join(terms, "+"), fitTitle),
+	}
+	pltr.nplots += len(plots)
+	return coefs, pltr.Cmd("%s %s", cmd, strings.Join(plots, ", "))
+}
+
+// PlotHistogramLog will create a histogram of the samples `data` with `bins`
+// logarithmically spaced bins spanning the range of the samples, eg. for
+// data following a power law, with `title` as the plot title.
+// The samples must be positive. The x-axis is switched to a logarithmic
+// scale, which is kept for subsequent plots.
+// Example:
+//  err = p.PlotHistogramLog([]float64{1, 3, 10, 12, 150, 2000}, 10, "my title")
+func (pltr *Plotter) PlotHistogramLog(data []float64, bins int, title string) error {
+	if bins <= 0 {
+		return &gnuplotError{fmt.Sprintf("invalid number of bins '%v'", bins)}
+	}
+	if len(data) == 0 {
+		return errNoData
+	}
+	lo, hi := math.Inf(1), 0.0
+	for _, v := range data {
+		if !(v > 0) || math.IsInf(v, 0) {
+			return &gnuplotError{fmt.Sprintf("invalid sample '%v' for a log histogram", v)}
+		}
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+	if lo == hi {
+		// Center the single bin on the samples, over a decade.
+		lo, hi = lo/math.Sqrt(10), hi*math.Sqrt(10)
+	}
+
+	ratio := math.Log(hi / lo)
+	counts := make([]int, bins)
+	for _, v := range data {
+		i := int(float64(bins) * math.Log(v/lo) / ratio)
+		if i >= bins {
+			i = bins - 1
+		}
+		counts[i]++
+	}
+
+	fname, err := pltr.writeTmpfile(func(w *dataWriter) {
+		for i, n := range counts {
+			left := lo * math.Exp(ratio*float64(i)/float64(bins))
+			right := lo * math.Exp(ratio*float64(i+1)/float64(bins))
+			// Boxes centered between the edges, with the bin width, span
+			// exactly the bin on the logarithmic axis.
+			w.Printf("%v %d %v\n", (left+right)/2, n, right-left)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = pltr.Cmd("set logscale x")
+	if err != nil {
+		return err
+	}
+	return pltr.plotData("plot", fname, "using 1:2:3", title, "boxes")
+}
+
+// PlotSurface will create a 3-d surface plot of the regular grid of heights
+// `z`, where z[j][i] is the height at (`xs[i]`, `ys[j]`), with `title` as the
+// plot title.
+// The surface is drawn with the pm3d style if it is the current style, and
+// as a wireframe with lines otherwise.
+// Example:
+//  err = p.PlotSurface(
+//           []float64{0, 1, 2},
+//           []float64{0, 1},
+//           [][]float64{{0, 1, 4}, {1, 2, 5}},
+//           "my title")
+func (pltr *Plotter) PlotSurface(xs, ys []float64, z [][]float64, title string) error {
+	if len(xs) == 0 || len(ys) == 0 {
+		return &gnuplotError{"empty grid"}
+	}
+	if len(z) != len(ys) {
+		return &gnuplotError{fmt.Sprintf(
+			"mismatched lengths: %d rows for %d y values", len(z), len(ys))}
+	}
+	for j, row := range z {
+		if len(row) != len(xs) {
+			return &gnuplotError{fmt.Sprintf(
+				"mismatched lengths: row %d has %d values for %d x values",
+				j, len(row), len(xs))}
+		}
+	}
+
+	// One scan line per y-coordinate, separated by blank lines.
+	fname, err := pltr.writeTmpfile(func(w *dataWriter) {
+		for j, y := range ys {
+			if j > 0 {
+				w.WriteString("\n")
+			}
+			for i, x := range xs {
+				w.Printf("%v %v %v\n", x, y, z[j][i])
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	with := "lines"
+	if pltr.style == "pm3d" {
+		with = "pm3d"
+	}
+	return pltr.plotData("splot", fname, "", title, with)
+}
@@ -0,0 +1,69 @@
+package gnuplot
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// plotComplex writes the points (`fx(i, c)`, `fy(c)`) for the elements `c`
+// of `data` and plots them. Elements with a NaN or infinite component are
+// replaced by a blank line, which leaves a gap in line plots.
+func (pltr *Plotter) plotComplex(data []complex128, fx func(i int, c complex128) float64,
+	fy func(c complex128) float64, title string) error {
+	if len(data) == 0 {
+		return errNoData
+	}
+
+	fname, err := pltr.writeTmpfile(func(w *dataWriter) {
+		for i, c := range data {
+			// cmplx.IsNaN is false when the other component is infinite.
+			re, im := real(c), imag(c)
+			if math.IsNaN(re) || math.IsNaN(im) || math.IsInf(re, 0) || math.IsInf(im, 0) {
+				w.WriteString("\n")
+				continue
+			}
+			w.Printf("%v %v\n", fx(i, c), fy(c))
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return pltr.plotData("plot", fname, "", title, pltr.style)
+}
+
+// complexIndex returns the index `i` of an element, as its x-coordinate.
+func complexIndex(i int, c complex128) float64 {
+	return float64(i)
+}
+
+// PlotComplexMag will create a 2-d plot of the magnitudes of `data`, using
+// the index of the elements as x-coordinates and `title` as the plot title.
+// Elements with a NaN or infinite component are skipped.
+// Example:
+//  err = p.PlotComplexMag([]complex128{1 + 1i, 2, -1i}, "my title")
+func (pltr *Plotter) PlotComplexMag(data []complex128, title string) error {
+	return pltr.plotComplex(data, complexIndex, cmplx.Abs, title)
+}
+
+// PlotComplexPhase will create a 2-d plot of the phases (in radians, within
+// [-Pi, Pi]) of `data`, using the index of the elements as x-coordinates and
+// `title` as the plot title.
+// Elements with a NaN or infinite component are skipped.
+// Example:
+//  err = p.PlotComplexPhase([]complex128{1 + 1i, 2, -1i}, "my title")
+func (pltr *Plotter) PlotComplexPhase(data []complex128, title string) error {
+	return pltr.plotComplex(data, complexIndex, cmplx.Phase, title)
+}
+
+// PlotArgand will create an Argand diagram of `data`, using the real parts
+// as x-coordinates, the imaginary parts as y-coordinates and `title` as the
+// plot title.
+// Elements with a NaN or infinite component are skipped.
+// Example:
+//  err = p.PlotArgand([]complex128{1 + 1i, 2, -1i}, "my title")
+func (pltr *Plotter) PlotArgand(data []complex128, title string) error {
+	re := func(i int, c complex128) float64 { return real(c) }
+	im := func(c complex128) float64 { return imag(c) }
+	return pltr.plotComplex(data, re, im, title)
+}
@@ -2,24 +2,71 @@
 // subprocess in order to plot data.
 // See the gnuplot documentation page for the exact semantics of the gnuplot
 // commands.
-//  http://www.gnuplot.info/
+//
+//	http://www.gnuplot.info/
 package gnuplot
 
 import (
+	"bufio"
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
+	"math/rand"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 )
 
 // Globals
 const (
 	gnuplotPrefix string = "go-gnuplot-"
+
+	// outputLines is the number of lines of gnuplot output kept until read.
+	outputLines = 1024
+
+	// queryTimeout is how long Query waits for the reply of gnuplot.
+	queryTimeout = 10 * time.Second
+
+	// defaultCloseTimeout is how long Close waits for the gnuplot subprocess
+	// to exit before killing it.
+	defaultCloseTimeout = 5 * time.Second
 )
 
 var gGnuplotCmd string
+var gGnuplotErr error // why gnuplot wasn't found in the PATH
+
+// allowedStyles lists the plotting styles accepted by SetStyle.
+var allowedStyles = []string{
+	"lines",
+	"points",
+	"linespoints",
+	"impulses",
+	"dots",
+	"steps",
+	"errorbars",
+	"boxes",
+	"boxerrorbars",
+	"pm3d",
+	"filledcurves",
+	"histeps",
+	"fsteps",
+	"financebars",
+	"vectors",
+	"circles",
+	"labels",
+	"image"}
 
 // Error type
 type gnuplotError struct {
@@ -30,6 +77,16 @@ func (e *gnuplotError) Error() string {
 	return e.err
 }
 
+// errExited is returned when sending commands to a dead gnuplot subprocess.
+var errExited = &gnuplotError{"gnuplot subprocess has exited"}
+
+// errDryRun is returned by the functions needing the replies of gnuplot in
+// dry-run mode.
+var errDryRun = &gnuplotError{"no gnuplot subprocess in dry-run mode"}
+
+// errNoData is returned by the plotting helpers given no data points.
+var errNoData = &gnuplotError{"no data points to plot"}
+
 // Helpers
 func min(a, b int) int {
 	if a < b {
@@ -38,37 +95,141 @@ func min(a, b int) int {
 	return b
 }
 
+// quote returns `s` as a double-quoted gnuplot string, escaping the
+// characters which would otherwise end or break the string.
+func quote(s string) string {
+	r := strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n")
+	return "\"" + r.Replace(s) + "\""
+}
+
+// validStyle reports whether `style` is one of the allowed plotting styles.
+func validStyle(style string) bool {
+	for _, s := range allowedStyles {
+		if s == style {
+			return true
+		}
+	}
+	return false
+}
+
 // Internal Functions
 
 // init is a function run on module load in Golang so this will be run before
 // anything else in the package.
+// A missing gnuplot in the PATH is only reported when creating a Plotter,
+// since the binary may also be given with WithBinary or GO_GNUPLOT_BIN.
 func init() {
-	var err error // Necessary to avoid shadowing the global
-	gGnuplotCmd, err = exec.LookPath("gnuplot")
-	if err != nil {
-		fmt.Printf("** could not find path to 'gnuplot':\n%v\n", err)
-		panic("could not find 'gnuplot'")
+	gGnuplotCmd, gGnuplotErr = exec.LookPath("gnuplot")
+	if gGnuplotErr != nil {
+		fmt.Printf("** could not find path to 'gnuplot':\n%v\n", gGnuplotErr)
+		return
 	}
 	fmt.Printf("-- found gnuplot command: %s\n", gGnuplotCmd)
 }
 
+// gnuplotPath returns the path of the gnuplot binary to run, by order of
+// precedence: `bin` if not empty, the GO_GNUPLOT_BIN environment variable if
+// set, or the gnuplot found in the PATH.
+func gnuplotPath(bin string) (string, error) {
+	if bin != "" {
+		return bin, nil
+	}
+	if env := os.Getenv("GO_GNUPLOT_BIN"); env != "" {
+		return env, nil
+	}
+	if gGnuplotErr != nil {
+		return "", gGnuplotErr
+	}
+	return gGnuplotCmd, nil
+}
+
 type plotterProcess struct {
-	handle *exec.Cmd
-	stdin  io.WriteCloser
+	handle  *exec.Cmd
+	stdin   io.WriteCloser
+	output  chan string   // lines printed by the subprocess on stdout/stderr
+	done    chan struct{} // closed once the subprocess has exited
+	err     error         // error returned by Wait, set before done is closed
+	queries int           // number of queries sent, to tell their replies apart
 }
 
-func newPlotterProc(persist bool) (*plotterProcess, error) {
+func newPlotterProc(bin string, persist bool) (*plotterProcess, error) {
+	path, err := gnuplotPath(bin)
+	if err != nil {
+		return nil, err
+	}
 	procArgs := []string{}
 	if persist {
 		procArgs = append(procArgs, "-persist")
 	}
-	fmt.Printf("--> [%v] %v\n", gGnuplotCmd, procArgs)
-	cmd := exec.Command(gGnuplotCmd, procArgs...)
+	fmt.Printf("--> [%v] %v\n", path, procArgs)
+	cmd := exec.Command(path, procArgs...)
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
 		return nil, err
 	}
-	return &plotterProcess{handle: cmd, stdin: stdin}, cmd.Start()
+	// The output is read from an os.Pipe rather than through exec's own
+	// copying, so that Wait doesn't wait for the helper processes forked by
+	// gnuplot (which share its output) to exit too.
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stdout = w
+	cmd.Stderr = w
+	proc := &plotterProcess{handle: cmd, stdin: stdin,
+		output: make(chan string, outputLines), done: make(chan struct{})}
+	err = cmd.Start()
+	w.Close()
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	go proc.read(r)
+	go proc.wait()
+	return proc, nil
+}
+
+// read forwards the lines printed by the subprocess to the output channel.
+// Lines are dropped when the channel is full, so that the subprocess never
+// blocks on its output.
+func (proc *plotterProcess) read(r io.ReadCloser) {
+	defer r.Close()
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		select {
+		case proc.output <- scanner.Text():
+		default:
+		}
+	}
+	// Keep draining the output if scanning failed.
+	io.Copy(ioutil.Discard, r)
+}
+
+// drain discards the pending output of the subprocess.
+func (proc *plotterProcess) drain() {
+	for {
+		select {
+		case <-proc.output:
+		default:
+			return
+		}
+	}
+}
+
+// wait waits for the subprocess to exit and records how it exited.
+func (proc *plotterProcess) wait() {
+	proc.err = proc.handle.Wait()
+	close(proc.done)
+}
+
+// exited reports whether the subprocess has exited.
+func (proc *plotterProcess) exited() bool {
+	select {
+	case <-proc.done:
+		return true
+	default:
+		return false
+	}
 }
 
 type tmpfilesDb map[string]*os.File
@@ -78,25 +239,315 @@ type tmpfilesDb map[string]*os.File
 // Plotter is a handle to a gnuplot subprocess, forwarding commands
 // via its stdin
 type Plotter struct {
+	mu       sync.Mutex // serializes the writes to the subprocess
 	proc     *plotterProcess
+	bin      string // gnuplot binary given with WithBinary
+	persist  bool
 	debug    bool
 	plotcmd  string
 	nplots   int    // number of currently active plots
 	style    string // current plotting style
 	tmpfiles tmpfilesDb
+	labels   []int // tags of the labels set by AddLabel
+	arrows   []int // tags of the arrows set by AddArrow
+	objects  []int // tags of the objects set by AddRectangle
+	polar    bool  // whether PlotPolar switched to polar mode
+
+	normalized []NormalizedRange // original ranges of PlotXYNormalized data
+
+	pointType int    // point type set with SetPointType, 0 for the default
+	dashType  string // dash type set with SetDashType, empty for the default
+
+	lineStyles map[int]bool // indices of the line styles set by DefineLineStyle
+	linestyle  int          // line style set with UseLineStyle, 0 for none
+
+	staged []string     // plot elements staged by AddSeries, see Render
+	config []string     // configuration commands, replayed by Restart
+	script bytes.Buffer // the commands sent, see Script
+	cmdLog io.Writer    // where to copy the commands sent, if not nil
+
+	timing func(cmd string, d time.Duration) // called after each logged write, if not nil
+
+	maxPoints    int           // maximum number of points per data file, 0 for no limit
+	closeTimeout time.Duration // how long Close waits for the subprocess
+	keepTmpfiles bool          // whether the data files are kept, for inspection
+	nanAsGap     bool          // whether PlotXY breaks lines at NaN values
+	dryRun       bool          // whether the commands are only recorded, see WithDryRun
+	scripted     bool          // whether the commands are kept for Script
+
+	seqNames bool   // whether the data files are named by sequence number
+	seqDir   string // directory of the data files named by sequence number
+	tmpSeq   int    // sequence number of the last data file
+
+	term          string   // terminal set with SetTerminal
+	termOpts      []string // options of the terminal
+	width, height int      // output size, 0 for the terminal default
+	font          string   // terminal font, as "name,size"
+	dpi           int      // resolution of raster terminals, 0 for the default
+	transparent   bool     // transparent background of png, pngcairo and gif
+	background    string   // background color of the terminal, if not empty
+	output        string   // output file left open by SaveToFile in append mode
+	appendOutput  bool     // append mode of SaveToFile, see SetOutputAppend
+	terminals     []string // cache of AvailableTerminals
+
+	precision int // significant digits of the data written, 0 for full precision
+}
+
+// writeTmpfile creates a new temporary data file, fills it by calling
+// `write` with a buffered writer on the file and returns the file name.
+func (pltr *Plotter) writeTmpfile(write func(w *dataWriter)) (string, error) {
+	f, err := pltr.newTmpfile()
+	if err != nil {
+		return "", err
+	}
+	w := pltr.newDataWriter(f)
+	write(w)
+	err = w.Flush()
+	cerr := f.Close()
+	// The file is closed, ResetPlot only has to remove it.
+	pltr.tmpfiles[f.Name()] = nil
+	if err != nil {
+		return "", err
+	}
+	return f.Name(), cerr
+}
+
+// dataWriter writes the data files, formatting the float64 values with the
+// precision set by SetDataPrecision.
+type dataWriter struct {
+	*bufio.Writer
+	precision int // significant digits, 0 for full precision
+}
+
+func (pltr *Plotter) newDataWriter(w io.Writer) *dataWriter {
+	return &dataWriter{bufio.NewWriter(w), pltr.precision}
+}
+
+// Printf is the same as fmt.Fprintf but rounds the float64 arguments.
+func (w *dataWriter) Printf(format string, a ...interface{}) {
+	if w.precision > 0 {
+		for i, v := range a {
+			if f, ok := v.(float64); ok {
+				a[i] = strconv.FormatFloat(f, 'g', w.precision, 64)
+			}
+		}
+	}
+	fmt.Fprintf(w, format, a...)
+}
+
+// newTmpfile creates a new temporary data file, with the ".dat" extension,
+// and registers it so that it is removed by ResetPlot.
+func (pltr *Plotter) newTmpfile() (*os.File, error) {
+	f, err := pltr.createTmpfile(".dat")
+	if err != nil {
+		return nil, err
+	}
+	pltr.tmpfiles[f.Name()] = f
+	return f, nil
+}
+
+// createTmpfile creates a new temporary file with the extension `ext`. With
+// WithDeterministicTempNames, the file is named by the next sequence number
+// of the Plotter not already taken in its directory.
+func (pltr *Plotter) createTmpfile(ext string) (*os.File, error) {
+	if !pltr.seqNames {
+		return tempFile(os.TempDir(), gnuplotPrefix, ext)
+	}
+	for {
+		pltr.tmpSeq++
+		fname := filepath.Join(pltr.seqDir, fmt.Sprintf("%s%d%s", gnuplotPrefix, pltr.tmpSeq, ext))
+		f, err := os.OpenFile(fname, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+		if !os.IsExist(err) {
+			return f, err
+		}
+	}
+}
+
+// tmpRand picks the names of the files created by tempFile.
+var tmpRand = struct {
+	sync.Mutex
+	*rand.Rand
+}{Rand: rand.New(rand.NewSource(time.Now().UnixNano() + int64(os.Getpid())))}
+
+// tempFile creates a new file in the directory `dir`, named by `prefix`, a
+// random number and `ext`. It is the same as ioutil.TempFile, but keeps the
+// extension that gnuplot relies on.
+func tempFile(dir, prefix, ext string) (*os.File, error) {
+	for i := 0; i < 100; i++ {
+		tmpRand.Lock()
+		n := tmpRand.Uint32()
+		tmpRand.Unlock()
+		fname := filepath.Join(dir, prefix+strconv.FormatUint(uint64(n), 10)+ext)
+		f, err := os.OpenFile(fname, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+		if !os.IsExist(err) {
+			return f, err
+		}
+	}
+	return nil, &gnuplotError{fmt.Sprintf("could not create a temporary file in '%s'", dir)}
+}
+
+// stride returns the step between the points written to data files, so
+// that at most maxPoints of the `npoints` points are written.
+func (pltr *Plotter) stride(npoints int) int {
+	if pltr.maxPoints <= 0 || npoints <= pltr.maxPoints {
+		return 1
+	}
+	return (npoints + pltr.maxPoints - 1) / pltr.maxPoints
+}
+
+// plotData sends the command plotting the data file `fname`. `mods` holds
+// the optional data modifiers (using, matrix, ...) placed before the title
+// and `with` the plotting style. `cmd` is turned into a "replot" if there
+// already are active plots.
+func (pltr *Plotter) plotData(cmd, fname, mods, title, with string) error {
+	if pltr.nplots > 0 {
+		cmd = "replot"
+	}
+
+	pltr.nplots++
+	with = pltr.lineStyle(with, pltr.linestyle)
+	return pltr.Cmd("%s %s", cmd, plotElement(fname, mods, title, with))
+}
+
+// lineStyle appends to the plotting style `with` the line style `ls`, if
+// not 0 and the style draws lines or points, the point type set with
+// SetPointType, if the style draws points, and the dash type set with
+// SetDashType, if it draws lines.
+func (pltr *Plotter) lineStyle(with string, ls int) string {
+	base := with
+	switch base {
+	case "lines", "points", "linespoints", "impulses", "dots",
+		"steps", "fsteps", "histeps":
+		if ls > 0 {
+			with += fmt.Sprintf(" linestyle %d", ls)
+		}
+	}
+	if pltr.pointType > 0 && (base == "points" || base == "linespoints") {
+		with += fmt.Sprintf(" pointtype %d", pltr.pointType)
+	}
+	switch base {
+	case "lines", "linespoints", "steps", "fsteps", "histeps":
+		if pltr.dashType != "" {
+			with += " dashtype " + pltr.dashType
+		}
+	}
+	return with
+}
+
+// plotElement returns the element of a plot command for the data file
+// `fname`, see plotData.
+func plotElement(fname, mods, title, with string) string {
+	elem := quote(fname)
+	if mods != "" {
+		elem += " " + mods
+	}
+	if title != "" {
+		elem += " title " + quote(title)
+	}
+	return elem + " with " + with
 }
 
 // Cmd sends a command to the gnuplot subprocess and returns an error
 // if something bad happened in the gnuplot process.
 // ex:
-//   fname := "foo.dat"
-//   err := p.Cmd("plot %s", fname)
-//   if err != nil {
-//     panic(err)
-//   }
+//
+//	fname := "foo.dat"
+//	err := p.Cmd("plot %s", fname)
+//	if err != nil {
+//	  panic(err)
+//	}
 func (pltr *Plotter) Cmd(format string, a ...interface{}) error {
-	cmd := fmt.Sprintf(format, a...) + "\n"
-	n, err := io.WriteString(pltr.proc.stdin, cmd)
+	cmd := fmt.Sprintf(format, a...)
+	pltr.record(cmd)
+	return pltr.send(cmd+"\n", true)
+}
+
+// CmdBatch sends the commands `cmds` to the gnuplot subprocess in a single
+// write, one per line, which saves a write per command for setup-heavy
+// figures. Unlike Cmd, the commands are sent as is rather than used as
+// format strings.
+// Example:
+//
+//	err = p.CmdBatch([]string{
+//	         "set grid",
+//	         "set key left top",
+//	         "set xlabel 'time'"})
+func (pltr *Plotter) CmdBatch(cmds []string) error {
+	if len(cmds) == 0 {
+		return nil
+	}
+	return pltr.cmds(cmds...)
+}
+
+// cmds sends the commands `cmds` to the gnuplot subprocess in a single write.
+func (pltr *Plotter) cmds(cmds ...string) error {
+	for _, cmd := range cmds {
+		pltr.record(cmd)
+	}
+	return pltr.transient(cmds...)
+}
+
+// transient is the same as cmds but for commands which only make sense
+// right now, which are not recorded for Restart.
+func (pltr *Plotter) transient(cmds ...string) error {
+	return pltr.send(strings.Join(cmds, "\n")+"\n", true)
+}
+
+// plumbing is the same as transient but for the internal commands of the
+// Plotter, such as the reply markers of Query, which are left out of the
+// script and the command log.
+func (pltr *Plotter) plumbing(cmds ...string) error {
+	return pltr.send(strings.Join(cmds, "\n")+"\n", false)
+}
+
+// record keeps track of the configuration commands ('set' and 'unset') so
+// that Restart can replay them. 'reset' forgets about them.
+func (pltr *Plotter) record(cmd string) {
+	fields := strings.Fields(cmd)
+	if len(fields) == 0 {
+		return
+	}
+	switch fields[0] {
+	case "set", "unset":
+		pltr.config = append(pltr.config, cmd)
+	case "reset":
+		pltr.config = nil
+	}
+}
+
+// send writes `cmd`, one or more newline-terminated commands, to the gnuplot
+// subprocess, and to the script and the command log if `logged`.
+func (pltr *Plotter) send(cmd string, logged bool) error {
+	var elapsed time.Duration
+	if logged && pltr.timing != nil {
+		// Deferred first so that it runs once the mutex is released.
+		defer func() { pltr.timing(strings.TrimSuffix(cmd, "\n"), elapsed) }()
+	}
+	pltr.mu.Lock()
+	defer pltr.mu.Unlock()
+
+	var n int
+	var err error
+	if !pltr.dryRun {
+		if pltr.proc.exited() {
+			return errExited
+		}
+		start := time.Now()
+		n, err = io.WriteString(pltr.proc.stdin, cmd)
+		elapsed = time.Since(start)
+		if err != nil && pltr.proc.exited() {
+			err = errExited
+		}
+	}
+	if logged && pltr.scripted {
+		pltr.script.WriteString(cmd)
+	}
+	if logged && pltr.cmdLog != nil {
+		_, lerr := io.WriteString(pltr.cmdLog, cmd)
+		if err == nil {
+			err = lerr
+		}
+	}
 
 	if pltr.debug {
 		//buf := new(bytes.Buffer)
@@ -108,11 +559,24 @@ func (pltr *Plotter) Cmd(format string, a ...interface{}) error {
 	return err
 }
 
+// Script returns the commands sent to the gnuplot subprocess since the
+// Plotter was created or last reset with ResetAll, one per line. Data is
+// referenced by the paths of the data files. The internal commands of the
+// Plotter, such as the ones waiting for gnuplot to catch up, are left out.
+// The commands are only kept with WithScript or WithDryRun, Script returns
+// an empty string otherwise.
+func (pltr *Plotter) Script() string {
+	pltr.mu.Lock()
+	defer pltr.mu.Unlock()
+	return pltr.script.String()
+}
+
 // CheckedCmd is a convenience wrapper around Cmd: it will panic if the
 // error returned by Cmd isn't nil.
 // ex:
-//   fname := "foo.dat"
-//   p.CheckedCmd("plot %s", fname)
+//
+//	fname := "foo.dat"
+//	p.CheckedCmd("plot %s", fname)
 func (pltr *Plotter) CheckedCmd(format string, a ...interface{}) {
 	err := pltr.Cmd(format, a...)
 	if err != nil {
@@ -120,29 +584,295 @@ func (pltr *Plotter) CheckedCmd(format string, a ...interface{}) {
 	}
 }
 
+// Query sends the command `cmd` to the gnuplot subprocess and returns the
+// text gnuplot printed in response, such as the output of 'print' and
+// 'show' commands or error messages.
+// The reply is delimited with a 'print' command, so the print output must
+// not be redirected with 'set print'.
+// Example:
+//
+//	version, err := p.Query("print GPVAL_VERSION")
+func (pltr *Plotter) Query(cmd string) (string, error) {
+	return pltr.query(cmd, queryTimeout)
+}
+
+// query is Query with a `timeout`, no timeout if zero.
+func (pltr *Plotter) query(cmd string, timeout time.Duration) (string, error) {
+	if pltr.dryRun {
+		return "", errDryRun
+	}
+	proc := pltr.proc
+	proc.drain()
+	proc.queries++
+	marker := fmt.Sprintf("%send-of-reply-%d", gnuplotPrefix, proc.queries)
+	if cmd != "" {
+		err := pltr.transient(cmd)
+		if err != nil {
+			return "", err
+		}
+	}
+	err := pltr.plumbing("print " + quote(marker))
+	if err != nil {
+		return "", err
+	}
+
+	lines := []string{}
+	var expired <-chan time.Time
+	if timeout > 0 {
+		expired = time.After(timeout)
+	}
+	for {
+		select {
+		case line := <-proc.output:
+			if line == marker {
+				return strings.Join(lines, "\n"), nil
+			}
+			lines = append(lines, line)
+		case <-proc.done:
+			return "", errExited
+		case <-expired:
+			return "", &gnuplotError{"timeout waiting for gnuplot"}
+		}
+	}
+}
+
+// Ping checks that the gnuplot subprocess is alive and responsive, by
+// waiting for it to answer a trivial query. It returns an error if the
+// subprocess has exited or doesn't answer within 10 seconds, eg. when it is
+// wedged.
+func (pltr *Plotter) Ping() error {
+	_, err := pltr.query("", queryTimeout)
+	return err
+}
+
+// identifier matches the names of gnuplot variables.
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SetVar sets the gnuplot user variable `name` to `value`, eg. to use it in
+// the expressions of PlotFuncExpr.
+// Example:
+//
+//	err = p.SetVar("a", 2.5)
+//	err = p.PlotFuncExpr("a*sin(x)", "my title")
+func (pltr *Plotter) SetVar(name string, value float64) error {
+	if !identifier.MatchString(name) {
+		return &gnuplotError{fmt.Sprintf("invalid variable name '%s'", name)}
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return &gnuplotError{fmt.Sprintf("invalid variable value '%v'", value)}
+	}
+	return pltr.Cmd("%s = %s", name, floatLiteral(value))
+}
+
+// floatLiteral formats `v` as a gnuplot floating point constant. gnuplot
+// reads the constants without a decimal point or exponent as integers, on
+// which it does integer arithmetic, eg. 1/2 is 0.
+func floatLiteral(v float64) string {
+	s := strconv.FormatFloat(v, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".en") {
+		s += ".0"
+	}
+	return s
+}
+
+// GetVar returns the value of the gnuplot user variable `name`, which must
+// hold a number.
+// Example:
+//
+//	v, err := p.GetVar("a")
+func (pltr *Plotter) GetVar(name string) (float64, error) {
+	if !identifier.MatchString(name) {
+		return 0, &gnuplotError{fmt.Sprintf("invalid variable name '%s'", name)}
+	}
+	reply, err := pltr.Query("print " + name)
+	if err != nil {
+		return 0, err
+	}
+	reply = strings.TrimSpace(reply)
+	v, err := strconv.ParseFloat(reply, 64)
+	if err != nil {
+		return 0, &gnuplotError{fmt.Sprintf("invalid value of variable '%s': %s", name, reply)}
+	}
+	return v, nil
+}
+
 // Close makes sure all resources used by the gnuplot subprocess are reclaimed.
 // This method is typically called when the Plotter instance is not needed
 // anymore. That's usually done via a defer statement:
-//   p, err := gnuplot.NewPlotter(...)
-//   if err != nil { /* handle error */ }
-//   defer p.Close()
+//
+//	p, err := gnuplot.NewPlotter(...)
+//	if err != nil { /* handle error */ }
+//	defer p.Close()
+//
+// If the subprocess doesn't exit within the close timeout (5 seconds by
+// default, see WithCloseTimeout), it is killed and a timeout error is
+// returned.
+// The data files of a Plotter garbage collected without being closed are
+// removed by a finalizer, but this is only a safety net: finalizers may run
+// late or not at all, so Close must still be called.
 func (pltr *Plotter) Close() (err error) {
 	if pltr.proc != nil && pltr.proc.handle != nil {
 		pltr.proc.stdin.Close()
-		err = pltr.proc.handle.Wait()
+		select {
+		case <-pltr.proc.done:
+			err = pltr.proc.err
+		case <-time.After(pltr.closeTimeout):
+			pltr.proc.handle.Process.Kill()
+			<-pltr.proc.done
+			err = &gnuplotError{fmt.Sprintf(
+				"timeout: gnuplot subprocess killed after %v", pltr.closeTimeout)}
+		}
 	}
+	pltr.output = ""
 	pltr.ResetPlot()
+	// Nothing left for finalize to clean up, Restart sets it again.
+	runtime.SetFinalizer(pltr, nil)
 	return err
 }
 
+// finalize is a best-effort cleanup for the Plotters collected without
+// having been closed: it removes their leftover data files and closes the
+// stdin of their subprocess so that it exits.
+func finalize(pltr *Plotter) {
+	for fname, fhandle := range pltr.tmpfiles {
+		if fhandle != nil {
+			fhandle.Close()
+		}
+		if !pltr.keepTmpfiles {
+			os.Remove(fname)
+		}
+	}
+	if pltr.proc != nil && pltr.proc.stdin != nil {
+		pltr.proc.stdin.Close()
+	}
+}
+
+// Restart spawns a new gnuplot subprocess for the Plotter, eg. after Close
+// or after the subprocess died, and replays the configuration commands
+// ('set' and 'unset' ones, since the last 'reset') sent so far, so that
+// labels, ranges, styles, ... are restored. Plots are not restored.
+// A running subprocess is closed first.
+func (pltr *Plotter) Restart() error {
+	if pltr.Running() {
+		pltr.Close()
+	}
+	pltr.ResetPlot()
+
+	if !pltr.dryRun {
+		proc, err := newPlotterProc(pltr.bin, pltr.persist)
+		if err != nil {
+			return err
+		}
+		pltr.proc = proc
+	}
+	runtime.SetFinalizer(pltr, finalize)
+	if len(pltr.config) == 0 {
+		return nil
+	}
+	return pltr.transient(pltr.config...)
+}
+
+// Clone creates a new Plotter with its own gnuplot subprocess, configured
+// like `pltr`: its settings and configuration commands (see Restart) are
+// replayed in the new subprocess. Plots, data files and the command log are
+// not shared with the original Plotter.
+// Example:
+//
+//	base, err := gnuplot.NewPlotter("", false, false)
+//	base.SetXLabel("time")
+//	p, err := base.Clone()
+func (pltr *Plotter) Clone() (*Plotter, error) {
+	p := &Plotter{
+		bin:          pltr.bin,
+		persist:      pltr.persist,
+		debug:        pltr.debug,
+		plotcmd:      pltr.plotcmd,
+		style:        pltr.style,
+		pointType:    pltr.pointType,
+		dashType:     pltr.dashType,
+		linestyle:    pltr.linestyle,
+		tmpfiles:     make(tmpfilesDb),
+		labels:       append([]int(nil), pltr.labels...),
+		arrows:       append([]int(nil), pltr.arrows...),
+		objects:      append([]int(nil), pltr.objects...),
+		polar:        pltr.polar,
+		config:       append([]string(nil), pltr.config...),
+		maxPoints:    pltr.maxPoints,
+		closeTimeout: pltr.closeTimeout,
+		keepTmpfiles: pltr.keepTmpfiles,
+		nanAsGap:     pltr.nanAsGap,
+		dryRun:       pltr.dryRun,
+		scripted:     pltr.scripted,
+		seqNames:     pltr.seqNames,
+		seqDir:       pltr.seqDir,
+		term:         pltr.term,
+		termOpts:     append([]string(nil), pltr.termOpts...),
+		width:        pltr.width,
+		height:       pltr.height,
+		font:         pltr.font,
+		dpi:          pltr.dpi,
+		transparent:  pltr.transparent,
+		background:   pltr.background,
+		appendOutput: pltr.appendOutput,
+		terminals:    pltr.terminals,
+		precision:    pltr.precision,
+	}
+
+	for index := range pltr.lineStyles {
+		if p.lineStyles == nil {
+			p.lineStyles = make(map[int]bool)
+		}
+		p.lineStyles[index] = true
+	}
+
+	if !p.dryRun {
+		proc, err := newPlotterProc(p.bin, p.persist)
+		if err != nil {
+			return nil, err
+		}
+		p.proc = proc
+	}
+	runtime.SetFinalizer(p, finalize)
+	if len(p.config) > 0 {
+		err := p.transient(p.config...)
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Running reports whether the gnuplot subprocess is still running.
+func (pltr *Plotter) Running() bool {
+	return pltr.proc != nil && !pltr.proc.exited()
+}
+
+// ActivePlots returns the number of active plots, ie. the plots drawn since
+// the last ResetPlot. The next plot is overlaid on them with 'replot' if it
+// isn't zero.
+func (pltr *Plotter) ActivePlots() int {
+	return pltr.nplots
+}
+
+// Replot redraws the active plots, eg. after changing a setting or the
+// output. It returns an error if there is no active plot.
+func (pltr *Plotter) Replot() error {
+	if pltr.nplots == 0 {
+		return &gnuplotError{"no active plot to replot"}
+	}
+	return pltr.Cmd("replot")
+}
+
 // PlotNd will create an n-dimensional plot (up to 3) with a title `title`
 // and using the data from the var-arg `data`.
 // example:
-//  err = p.PlotNd(
-//           "test Nd plot",
-//           []float64{0,1,2,3}, // x-data
-//           []float64{0,1,2,3}, // y-data
-//           []float64{0,1,2,3}) // z-data
+//
+//	err = p.PlotNd(
+//	         "test Nd plot",
+//	         []float64{0,1,2,3}, // x-data
+//	         []float64{0,1,2,3}, // y-data
+//	         []float64{0,1,2,3}) // z-data
 func (pltr *Plotter) PlotNd(title string, data ...[]float64) error {
 	ndims := len(data)
 
@@ -163,32 +893,29 @@ func (pltr *Plotter) PlotNd(title string, data ...[]float64) error {
 // The index of the element in the `data` slice will be used as the x-coordinate
 // and its correspinding value as the y-coordinate.
 // Example:
-//  err = p.PlotX([]float64{10, 20, 30}, "my title")
+//
+//	err = p.PlotX([]float64{10, 20, 30}, "my title")
 func (pltr *Plotter) PlotX(data []float64, title string) error {
-	f, err := ioutil.TempFile(os.TempDir(), gnuplotPrefix)
+	if len(data) == 0 {
+		return errNoData
+	}
+
+	fname, err := pltr.writeTmpfile(func(w *dataWriter) {
+		step := pltr.stride(len(data))
+		for i := 0; i < len(data); i += step {
+			if step > 1 {
+				// Keep the original indices as x-coordinates.
+				w.Printf("%v %v\n", i, data[i])
+			} else {
+				w.Printf("%v\n", data[i])
+			}
+		}
+	})
 	if err != nil {
 		return err
 	}
-	fname := f.Name()
-	pltr.tmpfiles[fname] = f
-	for _, d := range data {
-		f.WriteString(fmt.Sprintf("%v\n", d))
-	}
-	f.Close()
-	cmd := pltr.plotcmd
-	if pltr.nplots > 0 {
-		cmd = "replot"
-	}
 
-	var line string
-	if title == "" {
-		line = fmt.Sprintf("%s \"%s\" with %s", cmd, fname, pltr.style)
-	} else {
-		line = fmt.Sprintf("%s \"%s\" title \"%s\" with %s",
-			cmd, fname, title, pltr.style)
-	}
-	pltr.nplots++
-	return pltr.Cmd(line)
+	return pltr.plotData(pltr.plotcmd, fname, "", title, pltr.style)
 }
 
 // PlotXY will create a 2-d plot using `x` and `y` as input and `title` as
@@ -198,39 +925,225 @@ func (pltr *Plotter) PlotX(data []float64, title string) error {
 // If the lengths of the slices do not match, the range for the data will be
 // the smallest size of the two slices.
 // Example:
-//  err = p.PlotXY(
-//           []float64{10, 20, 30},
-//           []float64{11, 22, 33, 44},
-//           "my title")
+//
+//	err = p.PlotXY(
+//	         []float64{10, 20, 30},
+//	         []float64{11, 22, 33, 44},
+//	         "my title")
 func (pltr *Plotter) PlotXY(x, y []float64, title string) error {
+	return pltr.plotXY(x, y, title, pltr.style)
+}
+
+// plotXY is PlotXY with the plotting style `with`.
+func (pltr *Plotter) plotXY(x, y []float64, title, with string) error {
+	npoints := min(len(x), len(y))
+	if npoints == 0 {
+		return errNoData
+	}
+
+	fname, err := pltr.writeTmpfile(func(w *dataWriter) {
+		step := pltr.stride(npoints)
+		for i := 0; i < npoints; i += step {
+			if pltr.nanAsGap && math.IsNaN(y[i]) {
+				// A blank line breaks the line drawn by gnuplot.
+				w.WriteString("\n")
+				continue
+			}
+			w.Printf("%v %v\n", x[i], y[i])
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return pltr.plotData(pltr.plotcmd, fname, "", title, with)
+}
+
+// PlotXYLine is the same as PlotXY but always draws the points as a line,
+// whatever the current style.
+// Example:
+//
+//	err = p.PlotXYLine([]float64{0, 1, 2}, []float64{0, 1, 4}, "my title")
+func (pltr *Plotter) PlotXYLine(x, y []float64, title string) error {
+	return pltr.plotXY(x, y, title, "lines")
+}
+
+// PlotXYScatter is the same as PlotXY but always draws the points as
+// unconnected filled circles, whatever the current style.
+// Example:
+//
+//	err = p.PlotXYScatter([]float64{0, 1, 2}, []float64{0, 1, 4}, "my title")
+func (pltr *Plotter) PlotXYScatter(x, y []float64, title string) error {
+	return pltr.plotXY(x, y, title, "points pointtype 7 pointsize 1.2")
+}
+
+// PlotMapXY will create a 2-d plot using the keys of `m` as x-coordinates
+// and the matching values as y-coordinates, with `title` as the plot title.
+// The points are written sorted by ascending x-coordinate: gnuplot connects
+// the points in file order, so the random iteration order of maps would
+// produce a tangle of lines.
+// Example:
+//
+//	err = p.PlotMapXY(
+//	         map[float64]float64{0: 1, 1: 2, 2: 4},
+//	         "my title")
+func (pltr *Plotter) PlotMapXY(m map[float64]float64, title string) error {
+	x := make([]float64, 0, len(m))
+	for k := range m {
+		x = append(x, k)
+	}
+	sort.Float64s(x)
+
+	y := make([]float64, len(x))
+	for i, k := range x {
+		y[i] = m[k]
+	}
+	return pltr.PlotXY(x, y, title)
+}
+
+// PlotXYAxes is the same as PlotXY but plots the data against the pair of
+// axes `axes`, one of "x1y1", "x1y2", "x2y1" or "x2y2".
+// Example:
+//
+//	err = p.PlotXYAxes(
+//	         []float64{10, 20, 30},
+//	         []float64{1100, 2200, 3300},
+//	         "my title",
+//	         "x1y2")
+func (pltr *Plotter) PlotXYAxes(x, y []float64, title string, axes string) error {
+	err := checkAxes(axes)
+	if err != nil {
+		return err
+	}
 	npoints := min(len(x), len(y))
+	if npoints == 0 {
+		return errNoData
+	}
 
-	f, err := ioutil.TempFile(os.TempDir(), gnuplotPrefix)
+	fname, err := pltr.writeTmpfile(func(w *dataWriter) {
+		step := pltr.stride(npoints)
+		for i := 0; i < npoints; i += step {
+			w.Printf("%v %v\n", x[i], y[i])
+		}
+	})
 	if err != nil {
 		return err
 	}
-	fname := f.Name()
-	pltr.tmpfiles[fname] = f
 
-	for i := 0; i < npoints; i++ {
-		f.WriteString(fmt.Sprintf("%v %v\n", x[i], y[i]))
+	return pltr.plotData(pltr.plotcmd, fname, "axes "+axes, title, pltr.style)
+}
+
+// PlotXYSmooth will create a 2-d plot of the curve smoothed through the
+// points (`x`, `y`) with `title` as the plot title.
+// `method` is the gnuplot smoothing method, one of:
+//
+//	"unique",
+//	"frequency",
+//	"cumulative",
+//	"csplines",
+//	"acsplines",
+//	"mcsplines",
+//	"bezier",
+//	"sbezier"
+//
+// If the lengths of the slices do not match, the range for the data will be
+// the smallest size of the two slices.
+// Example:
+//
+//	err = p.PlotXYSmooth(
+//	         []float64{0, 1, 2, 3},
+//	         []float64{0, 2, 1, 3},
+//	         "bezier",
+//	         "my title")
+func (pltr *Plotter) PlotXYSmooth(x, y []float64, method, title string) error {
+	switch method {
+	case "unique", "frequency", "cumulative", "csplines", "acsplines",
+		"mcsplines", "bezier", "sbezier":
+	default:
+		return &gnuplotError{fmt.Sprintf("invalid smoothing method '%s'", method)}
+	}
+	npoints := min(len(x), len(y))
+	if npoints == 0 {
+		return errNoData
 	}
 
-	f.Close()
-	cmd := pltr.plotcmd
-	if pltr.nplots > 0 {
-		cmd = "replot"
+	fname, err := pltr.writeTmpfile(func(w *dataWriter) {
+		step := pltr.stride(npoints)
+		for i := 0; i < npoints; i += step {
+			w.Printf("%v %v\n", x[i], y[i])
+		}
+	})
+	if err != nil {
+		return err
 	}
 
-	var line string
-	if title == "" {
-		line = fmt.Sprintf("%s \"%s\" with %s", cmd, fname, pltr.style)
-	} else {
-		line = fmt.Sprintf("%s \"%s\" title \"%s\" with %s",
-			cmd, fname, title, pltr.style)
+	return pltr.plotData("plot", fname, "smooth "+method, title, "lines")
+}
+
+// NormalizedRange is the original range of the data of a plot created by
+// PlotXYNormalized, eg. to note it in the axis labels.
+type NormalizedRange struct {
+	Title      string
+	XMin, XMax float64
+	YMin, YMax float64
+}
+
+// normalize maps `v` linearly from [lo, hi] to [0, 1], or to 0.5 if the
+// range is empty.
+func normalize(v, lo, hi float64) float64 {
+	if hi == lo {
+		return 0.5
 	}
-	pltr.nplots++
-	return pltr.Cmd(line)
+	return (v - lo) / (hi - lo)
+}
+
+// PlotXYNormalized is the same as PlotXY but min-max normalizes both the
+// x- and y-coordinates to [0, 1] before plotting, so that data of different
+// scales can be compared. Constant data is mapped to 0.5.
+// The original ranges are recorded and returned by NormalizedRanges.
+// Example:
+//
+//	err = p.PlotXYNormalized(
+//	         []float64{0, 10, 20},
+//	         []float64{100, 400, 200},
+//	         "my title")
+func (pltr *Plotter) PlotXYNormalized(x, y []float64, title string) error {
+	npoints := min(len(x), len(y))
+	if npoints == 0 {
+		return errNoData
+	}
+	r := NormalizedRange{title, x[0], x[0], y[0], y[0]}
+	for i := 1; i < npoints; i++ {
+		r.XMin = math.Min(r.XMin, x[i])
+		r.XMax = math.Max(r.XMax, x[i])
+		r.YMin = math.Min(r.YMin, y[i])
+		r.YMax = math.Max(r.YMax, y[i])
+	}
+
+	fname, err := pltr.writeTmpfile(func(w *dataWriter) {
+		step := pltr.stride(npoints)
+		for i := 0; i < npoints; i += step {
+			w.Printf("%v %v\n",
+				normalize(x[i], r.XMin, r.XMax),
+				normalize(y[i], r.YMin, r.YMax))
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = pltr.plotData(pltr.plotcmd, fname, "", title, pltr.style)
+	if err != nil {
+		return err
+	}
+	pltr.normalized = append(pltr.normalized, r)
+	return nil
+}
+
+// NormalizedRanges returns the original ranges of the data plotted with
+// PlotXYNormalized since the last ResetPlot, in plotting order.
+func (pltr *Plotter) NormalizedRanges() []NormalizedRange {
+	return append([]NormalizedRange(nil), pltr.normalized...)
 }
 
 // PlotXYZ will create a 3-d plot using `x`, `y` and `z` as input and
@@ -238,40 +1151,59 @@ func (pltr *Plotter) PlotXY(x, y []float64, title string) error {
 // The data points to be plotted are the triplets (x[i], y[i], z[i]) where
 // `i` runs from 0 to the smallest length of the 3 slices.
 // Example:
-//  err = p.PlotXYZ(
-//           []float64{10, 20, 30},
-//           []float64{11, 22, 33, 44},
-//           []float64{111, 222, 333, 444, 555},
-//           "my title")
+//
+//	err = p.PlotXYZ(
+//	         []float64{10, 20, 30},
+//	         []float64{11, 22, 33, 44},
+//	         []float64{111, 222, 333, 444, 555},
+//	         "my title")
 func (pltr *Plotter) PlotXYZ(x, y, z []float64, title string) error {
 	npoints := min(len(x), len(y))
 	npoints = min(npoints, len(z))
-	f, err := ioutil.TempFile(os.TempDir(), gnuplotPrefix)
+	if npoints == 0 {
+		return errNoData
+	}
+
+	fname, err := pltr.writeTmpfile(func(w *dataWriter) {
+		step := pltr.stride(npoints)
+		for i := 0; i < npoints; i += step {
+			w.Printf("%v %v %v\n", x[i], y[i], z[i])
+		}
+	})
 	if err != nil {
 		return err
 	}
-	fname := f.Name()
-	pltr.tmpfiles[fname] = f
 
-	for i := 0; i < npoints; i++ {
-		f.WriteString(fmt.Sprintf("%v %v %v\n", x[i], y[i], z[i]))
-	}
+	return pltr.plotData("splot", fname, "", title, pltr.style) // Force 3D plot
+}
 
-	f.Close()
-	cmd := "splot" // Force 3D plot
-	if pltr.nplots > 0 {
-		cmd = "replot"
+// PlotXYZScatter is the same as PlotXYZ but always plots the triplets as
+// unconnected points, whatever the current style, for 3-d scatter plots.
+// Example:
+//
+//	err = p.PlotXYZScatter(
+//	         []float64{1, 2, 3},
+//	         []float64{4, 1, 3},
+//	         []float64{2, 5, 1},
+//	         "my title")
+func (pltr *Plotter) PlotXYZScatter(x, y, z []float64, title string) error {
+	npoints := min(len(x), len(y))
+	npoints = min(npoints, len(z))
+	if npoints == 0 {
+		return errNoData
 	}
 
-	var line string
-	if title == "" {
-		line = fmt.Sprintf("%s \"%s\" with %s", cmd, fname, pltr.style)
-	} else {
-		line = fmt.Sprintf("%s \"%s\" title \"%s\" with %s",
-			cmd, fname, title, pltr.style)
+	fname, err := pltr.writeTmpfile(func(w *dataWriter) {
+		step := pltr.stride(npoints)
+		for i := 0; i < npoints; i += step {
+			w.Printf("%v %v %v\n", x[i], y[i], z[i])
+		}
+	})
+	if err != nil {
+		return err
 	}
-	pltr.nplots++
-	return pltr.Cmd(line)
+
+	return pltr.plotData("splot", fname, "", title, "points")
 }
 
 // Func is a 1-d function which can be plotted with gnuplot
@@ -280,25 +1212,63 @@ type Func func(x float64) float64
 // PlotFunc will create a 2-d plot using `data` as x-coordinates and `fct(x[i])`
 // as the y-coordinates.
 // Example:
-//  fct := funct (x float64) float64 { return math.Exp(float64(x) + 2.) }
-//  err = p.PlotFunc(
-//           []float64{0,1,2,3,4,5},
-//           fct,
-//           "my title")
+//
+//	fct := funct (x float64) float64 { return math.Exp(float64(x) + 2.) }
+//	err = p.PlotFunc(
+//	         []float64{0,1,2,3,4,5},
+//	         fct,
+//	         "my title")
 func (pltr *Plotter) PlotFunc(data []float64, fct Func, title string) error {
+	if len(data) == 0 {
+		return errNoData
+	}
 
-	f, err := ioutil.TempFile(os.TempDir(), gnuplotPrefix)
+	fname, err := pltr.writeTmpfile(func(w *dataWriter) {
+		step := pltr.stride(len(data))
+		for i := 0; i < len(data); i += step {
+			w.Printf("%v %v\n", data[i], fct(data[i]))
+		}
+	})
 	if err != nil {
 		return err
 	}
-	fname := f.Name()
-	pltr.tmpfiles[fname] = f
 
-	for _, x := range data {
-		f.WriteString(fmt.Sprintf("%v %v\n", x, fct(x)))
+	return pltr.plotData(pltr.plotcmd, fname, "", title, pltr.style)
+}
+
+// PlotFuncRange is the same as PlotFunc but samples `fct` at `n` evenly
+// spaced x-coordinates spanning [`lo`, `hi`].
+// Example:
+//
+//	err = p.PlotFuncRange(math.Sin, 0, 2*math.Pi, 100, "my title")
+func (pltr *Plotter) PlotFuncRange(fct Func, lo, hi float64, n int, title string) error {
+	if fct == nil {
+		return &gnuplotError{"nil function"}
+	}
+	if n < 2 {
+		return &gnuplotError{fmt.Sprintf("invalid number of samples '%v'", n)}
+	}
+	if !(lo < hi) || math.IsInf(lo, 0) || math.IsInf(hi, 0) {
+		return &gnuplotError{fmt.Sprintf("invalid function range '[%v:%v]'", lo, hi)}
 	}
+	data := make([]float64, n)
+	for i := range data {
+		data[i] = lo + (hi-lo)*float64(i)/float64(n-1)
+	}
+	return pltr.PlotFunc(data, fct, title)
+}
 
-	f.Close()
+// PlotFuncExpr will create a plot of the gnuplot expression `expr` with
+// `title` as the plot title.
+// The expression is sampled by gnuplot itself over the current x-range, see
+// SetSamples to control the number of samples.
+// Example:
+//
+//	err = p.PlotFuncExpr("sin(x)/x", "my title")
+func (pltr *Plotter) PlotFuncExpr(expr, title string) error {
+	if strings.TrimSpace(expr) == "" {
+		return &gnuplotError{"empty expression"}
+	}
 	cmd := pltr.plotcmd
 	if pltr.nplots > 0 {
 		cmd = "replot"
@@ -306,13 +1276,170 @@ func (pltr *Plotter) PlotFunc(data []float64, fct Func, title string) error {
 
 	var line string
 	if title == "" {
-		line = fmt.Sprintf("%s \"%s\" with %s", cmd, fname, pltr.style)
+		line = fmt.Sprintf("%s %s with %s", cmd, expr, pltr.lineStyle(pltr.style, pltr.linestyle))
 	} else {
-		line = fmt.Sprintf("%s \"%s\" title \"%s\" with %s",
-			cmd, fname, title, pltr.style)
+		line = fmt.Sprintf("%s %s title %s with %s",
+			cmd, expr, quote(title), pltr.lineStyle(pltr.style, pltr.linestyle))
 	}
 	pltr.nplots++
-	return pltr.Cmd(line)
+	return pltr.Cmd("%s", line)
+}
+
+// FileOption is an option of the plots of existing data files.
+type FileOption func(*fileOptions)
+
+type fileOptions struct {
+	index  int    // data block to plot, -1 for all of them
+	every  string // point and block decimation
+	header int    // column whose header is the title, 0 for none
+}
+
+// FileIndex selects the data block `index` (starting from 0) of a data file
+// whose blocks are separated by pairs of blank lines.
+func FileIndex(index int) FileOption {
+	return func(o *fileOptions) {
+		o.index = index
+	}
+}
+
+// FileEvery selects a subset of the points of a data file with gnuplot's
+// 'every' modifier, eg. "10" for every 10th point.
+func FileEvery(every string) FileOption {
+	return func(o *fileOptions) {
+		o.every = every
+	}
+}
+
+// FileColumnHeader titles the plot with the header of the column `column`
+// (starting from 1), read from the first row of the data file, in place of
+// the title given to PlotFileUsing.
+func FileColumnHeader(column int) FileOption {
+	return func(o *fileOptions) {
+		o.header = column
+	}
+}
+
+// PlotFileUsing will create a plot of the existing data file `path` using
+// the gnuplot column selection `using`, with `title` as the plot title and
+// `style` as the plotting style (see SetStyle for the allowed styles).
+// The data file isn't managed by the Plotter: it is neither copied nor
+// removed.
+// Example:
+//
+//	err = p.PlotFileUsing("data.txt", "1:3", "my title", "lines",
+//	         gnuplot.FileEvery("10"))
+func (pltr *Plotter) PlotFileUsing(path, using, title, style string, opts ...FileOption) error {
+	if strings.TrimSpace(using) == "" {
+		return &gnuplotError{"empty using specification"}
+	}
+	if !validStyle(style) {
+		return &gnuplotError{fmt.Sprintf("invalid style '%s'", style)}
+	}
+	o := fileOptions{index: -1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.header < 0 {
+		return &gnuplotError{fmt.Sprintf("invalid header column '%v'", o.header)}
+	}
+
+	mods := ""
+	if o.index >= 0 {
+		mods += fmt.Sprintf("index %d ", o.index)
+	}
+	if o.every != "" {
+		mods += fmt.Sprintf("every %s ", o.every)
+	}
+	mods += "using " + using
+	if o.header > 0 {
+		mods += fmt.Sprintf(" title columnheader(%d)", o.header)
+		title = ""
+	}
+	return pltr.plotData(pltr.plotcmd, path, mods, title, style)
+}
+
+// SetAutoTitleColumnHead turns on or off the titling of the plots without
+// a title with the header of their column, read from the first row of the
+// data files, eg. for self-describing data files plotted with
+// PlotFileUsing. Off, the plots without a title are titled by gnuplot's
+// default.
+// The first row of all the data files is read as a header while on,
+// including the ones written by the plotting helpers, so turn it off before
+// using them.
+// Example:
+//
+//	err = p.SetAutoTitleColumnHead(true)
+//	err = p.PlotFileUsing("data.txt", "1:2", "", "lines")
+func (pltr *Plotter) SetAutoTitleColumnHead(on bool) error {
+	if on {
+		return pltr.Cmd("set key autotitle columnhead")
+	}
+	return pltr.Cmd("set key autotitle")
+}
+
+// SetDataSeparator changes the column separator of the data files, eg. to
+// plot CSV files with PlotFileUsing. `sep` is a single character or one of
+// the keywords "whitespace" (the default), "tab" and "comma".
+// The separator applies to all the data files, including the ones written
+// by the plotting helpers, so restore "whitespace" before using them.
+// Example:
+//
+//	err = p.SetDataSeparator("comma")
+//	err = p.PlotFileUsing("data.csv", "1:2", "my title", "lines")
+func (pltr *Plotter) SetDataSeparator(sep string) error {
+	switch {
+	case sep == "whitespace" || sep == "tab" || sep == "comma":
+		return pltr.Cmd("set datafile separator %s", sep)
+	case utf8.RuneCountInString(sep) == 1 && sep != "\n":
+		return pltr.Cmd("set datafile separator %s", quote(sep))
+	}
+	return &gnuplotError{fmt.Sprintf("invalid data separator '%s'", sep)}
+}
+
+// SetMissingValue changes the token marking a missing value in the data
+// files, eg. "?" or "NaN", so that the rows holding it are treated as gaps
+// rather than as errors or zeros. With the line-connecting styles (lines,
+// linespoints, steps, ...) the line is interrupted at those rows.
+// Example:
+//
+//	err = p.SetMissingValue("?")
+func (pltr *Plotter) SetMissingValue(token string) error {
+	if token == "" || strings.ContainsAny(token, " \t\r\n") {
+		return &gnuplotError{fmt.Sprintf("invalid missing value token '%s'", token)}
+	}
+	return pltr.Cmd("set datafile missing %s", quote(token))
+}
+
+// SetSamples changes the number of points at which gnuplot samples the
+// functions it plots (100 by default).
+func (pltr *Plotter) SetSamples(n int) error {
+	if n <= 1 {
+		return &gnuplotError{fmt.Sprintf("invalid number of samples '%v'", n)}
+	}
+	return pltr.Cmd("set samples %d", n)
+}
+
+// SetIsoSamples changes the number of isolines gnuplot draws for the
+// surfaces it plots (10 by default).
+func (pltr *Plotter) SetIsoSamples(n int) error {
+	if n <= 1 {
+		return &gnuplotError{fmt.Sprintf("invalid number of iso-samples '%v'", n)}
+	}
+	return pltr.Cmd("set isosamples %d", n)
+}
+
+// SetDataPrecision changes the number of significant digits of the values
+// written to the data files, which makes the files smaller and their content
+// reproducible. 0, the default, writes the values with full precision.
+// Example:
+//
+//	err = p.SetDataPrecision(6)
+func (pltr *Plotter) SetDataPrecision(digits int) error {
+	if digits < 0 {
+		return &gnuplotError{fmt.Sprintf("invalid data precision '%v'", digits)}
+	}
+	pltr.precision = digits
+	return nil
 }
 
 // SetPlotCmd changes the command used for plotting by the gnuplot subprocess.
@@ -328,44 +1455,111 @@ func (pltr *Plotter) SetPlotCmd(cmd string) (err error) {
 }
 
 // SetStyle changes the style used by the gnuplot subprocess.
-// Only valid styles are accepted:
-//    "lines",
-//    "points",
-//    "linespoints",
-// 		"impulses",
-//    "dots",
-// 		"steps",
-// 		"errorbars",
-// 		"boxes",
-// 		"boxerrorbars",
-// 		"pm3d"
-func (pltr *Plotter) SetStyle(style string) (err error) {
-	allowed := []string{
-		"lines",
-		"points",
-		"linespoints",
-		"impulses",
-		"dots",
-		"steps",
-		"errorbars",
-		"boxes",
-		"boxerrorbars",
-		"pm3d"}
-
-	for _, s := range allowed {
-		if s == style {
-			pltr.style = style
-			err = nil
-			return err
-		}
+// Only valid styles are accepted, see Styles for the list. On an invalid
+// style the current style is kept and an error is returned.
+// Example:
+//
+//	err = p.SetStyle("linespoints")
+func (pltr *Plotter) SetStyle(style string) error {
+	if !validStyle(style) {
+		return &gnuplotError{fmt.Sprintf("invalid style '%s'", style)}
 	}
+	pltr.style = style
+	return nil
+}
 
-	fmt.Printf("** style '%v' not in allowed list %v\n", style, allowed)
-	fmt.Printf("** default to 'points'\n")
-	pltr.style = "points"
-	err = &gnuplotError{fmt.Sprintf("invalid style '%s'", style)}
+// maxPointType is the highest point type accepted by SetPointType. Point
+// types are terminal dependent, but all terminals have at least 15.
+const maxPointType = 15
 
-	return err
+// SetPointType changes the point type (marker shape) of the plots drawn with
+// the "points" and "linespoints" styles to `pt`, from 1 to 15, eg. 5 for
+// filled squares or 7 for filled circles. 0 restores the default point type.
+// Example:
+//
+//	err = p.SetPointType(7)
+func (pltr *Plotter) SetPointType(pt int) error {
+	if pt < 0 || pt > maxPointType {
+		return &gnuplotError{fmt.Sprintf("invalid point type '%v'", pt)}
+	}
+	pltr.pointType = pt
+	return nil
+}
+
+// dashTypes maps the dash pattern names accepted by SetDashType to gnuplot
+// dash types.
+var dashTypes = map[string]string{
+	"solid":   "solid",
+	"dashed":  "2",
+	"dotted":  "3",
+	"dashdot": "4",
+}
+
+// dashSpec matches the explicit dash patterns: a list of dash and gap
+// lengths such as "(5,3,2,3)", or a string of dash characters such as ".-_".
+var dashSpec = regexp.MustCompile(`^(\(\s*[0-9.]+(\s*,\s*[0-9.]+)*\s*\)|[-._ ]+)$`)
+
+// SetDashType changes the dash pattern of the plots drawn with the line
+// styles ("lines", "linespoints" and the steps styles) to `dt`, one of:
+//
+//	"solid", "dashed", "dotted", "dashdot",
+//
+// or an explicit pattern: a list of dash and gap lengths such as
+// "(5,3,2,3)", or a string of dash characters such as ".-_".
+// An empty `dt` restores the default dash type.
+// Example:
+//
+//	err = p.SetDashType("dashed")
+func (pltr *Plotter) SetDashType(dt string) error {
+	switch {
+	case dt == "":
+		pltr.dashType = ""
+	case dashTypes[dt] != "":
+		pltr.dashType = dashTypes[dt]
+	case dashSpec.MatchString(dt):
+		if dt[0] == '(' {
+			pltr.dashType = dt
+		} else {
+			pltr.dashType = quote(dt)
+		}
+	default:
+		return &gnuplotError{fmt.Sprintf("invalid dash type '%s'", dt)}
+	}
+	return nil
+}
+
+// Styles returns the plotting styles accepted by SetStyle:
+//
+//	"lines",
+//	"points",
+//	"linespoints",
+//	"impulses",
+//	"dots",
+//	"steps",
+//	"errorbars",
+//	"boxes",
+//	"boxerrorbars",
+//	"pm3d",
+//	"filledcurves",
+//	"histeps",
+//	"fsteps",
+//	"financebars",
+//	"vectors",
+//	"circles",
+//	"labels",
+//	"image"
+//
+// Most styles plot x y pairs, some need more data columns and are meant for
+// PlotNd or PlotFileUsing:
+//
+//	"financebars": x open low high close,
+//	"vectors": x y dx dy,
+//	"circles": x y radius,
+//	"labels": x y text,
+//	"image": x y value (on a regular grid),
+//	"filledcurves": x y, or x y1 y2 to fill between two curves.
+func Styles() []string {
+	return append([]string(nil), allowedStyles...)
 }
 
 // SetXLabel changes the label for the x-axis
@@ -383,10 +1577,21 @@ func (pltr *Plotter) SetZLabel(label string) error {
 	return pltr.Cmd(fmt.Sprintf("set zlabel '%s'", label))
 }
 
+// SetY2Label changes the label for the secondary y-axis
+func (pltr *Plotter) SetY2Label(label string) error {
+	return pltr.Cmd("set y2label '%s'", label)
+}
+
+// SetX2Label changes the label for the secondary (top) x-axis
+func (pltr *Plotter) SetX2Label(label string) error {
+	return pltr.Cmd("set x2label '%s'", label)
+}
+
 // SetLabels changes the labels for the x-,y- and z-axis in one go, depending
 // on the size of the `labels` var-arg.
 // Example:
-//  err = p.SetLabels("x", "y", "z")
+//
+//	err = p.SetLabels("x", "y", "z")
 func (pltr *Plotter) SetLabels(labels ...string) error {
 	ndims := len(labels)
 	if ndims > 3 || ndims <= 0 {
@@ -419,42 +1624,228 @@ func (pltr *Plotter) SetLabels(labels ...string) error {
 	return nil
 }
 
+// subtitleScale is the font scale of the subtitles of SetTitle.
+const subtitleScale = 0.8
+
+// SetTitle changes the title of the plot to `title`. An optional `subtitle`
+// is added on a second line, in a smaller font. The subtitle relies on the
+// enhanced text mode of the terminal, the default of most terminals.
+// Example:
+//
+//	err = p.SetTitle("Temperature", "Paris, 2023")
+func (pltr *Plotter) SetTitle(title string, subtitle ...string) error {
+	if len(subtitle) > 1 {
+		return &gnuplotError{fmt.Sprintf("invalid number of subtitles '%v'", len(subtitle))}
+	}
+	if len(subtitle) == 1 && subtitle[0] != "" {
+		// Escape the characters of the enhanced text mode in the subtitle.
+		r := strings.NewReplacer("\\", "\\\\", "^", "\\^", "_", "\\_",
+			"@", "\\@", "&", "\\&", "~", "\\~", "{", "\\{", "}", "\\}")
+		title += fmt.Sprintf("\n{/*%v %s}", subtitleScale, r.Replace(subtitle[0]))
+	}
+	return pltr.Cmd("set title %s", quote(title))
+}
+
+// SetTitleOffset moves the title of the plot by `x` characters to the right
+// and `y` characters up from its default position.
+// Example:
+//
+//	err = p.SetTitleOffset(0, -1)
+func (pltr *Plotter) SetTitleOffset(x, y float64) error {
+	for _, v := range []float64{x, y} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return &gnuplotError{fmt.Sprintf("invalid title offset '%v'", v)}
+		}
+	}
+	return pltr.Cmd("set title offset %v,%v", x, y)
+}
+
 // ResetPlot clears up all plots and sets the Plotter state anew.
 func (pltr *Plotter) ResetPlot() (err error) {
 	for fname, fhandle := range pltr.tmpfiles {
-		ferr := fhandle.Close()
-		if ferr != nil {
-			err = ferr
+		if fhandle != nil {
+			if ferr := fhandle.Close(); ferr != nil {
+				err = ferr
+			}
 		}
-		os.Remove(fname)
+		if pltr.keepTmpfiles {
+			fmt.Printf("** kept data file '%s'\n", fname)
+		} else {
+			os.Remove(fname)
+		}
+		delete(pltr.tmpfiles, fname)
 	}
 	pltr.nplots = 0
+	pltr.staged = nil
+	pltr.normalized = nil
+	if pltr.polar {
+		// Leave the polar mode entered by PlotPolar.
+		pltr.polar = false
+		if pltr.Running() || pltr.dryRun {
+			err = pltr.Cmd("unset polar")
+		}
+	}
 	return err
 }
 
+// ResetAll clears up all plots like ResetPlot and also restores all the
+// gnuplot settings (labels, ranges, styles, ...) to their default values.
+// This is the way to go when reusing a Plotter for unrelated figures.
+func (pltr *Plotter) ResetAll() error {
+	err := pltr.ResetPlot()
+	cerr := pltr.Cmd("reset")
+	pltr.labels = nil
+	pltr.arrows = nil
+	pltr.objects = nil
+	pltr.lineStyles = nil
+	pltr.linestyle = 0
+
+	pltr.mu.Lock()
+	pltr.script.Reset()
+	pltr.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return cerr
+}
+
 // NewPlotter creates a new Plotter instance.
-//  - `fname` is the name of the file containing commands (should be empty for now)
-//  - `persist` is a flag to run the gnuplot subprocess with '-persist' so the
+//   - `fname` is the name of the file containing commands (should be empty for now)
+//   - `persist` is a flag to run the gnuplot subprocess with '-persist' so the
 //     plot window isn't closed after sending a command
-//  - `debug` is a flag to tell go-gnuplot to print out every command sent to
+//   - `debug` is a flag to tell go-gnuplot to print out every command sent to
 //     the gnuplot subprocess.
+//
 // Example:
-//  p, err := gnuplot.NewPlotter("", false, false)
-//  if err != nil { /* handle error */ }
-//  defer p.Close()
+//
+//	p, err := gnuplot.NewPlotter("", false, false)
+//	if err != nil { /* handle error */ }
+//	defer p.Close()
 func NewPlotter(fname string, persist, debug bool) (*Plotter, error) {
-	p := &Plotter{proc: nil, debug: debug, plotcmd: "plot",
-		nplots: 0, style: "points"}
-	p.tmpfiles = make(tmpfilesDb)
-
 	if fname != "" {
 		panic("NewPlotter with fname is not yet supported")
-	} else {
-		proc, err := newPlotterProc(persist)
+	}
+
+	opts := []Option{}
+	if persist {
+		opts = append(opts, WithPersist())
+	}
+	if debug {
+		opts = append(opts, WithDebug())
+	}
+	return NewPlotterWithOptions(opts...)
+}
+
+// NewPlotterWithOptions creates a new Plotter instance configured by `opts`.
+// The environment can provide defaults for headless deployments, used when
+// the matching option isn't given:
+//   - GO_GNUPLOT_BIN is the gnuplot binary to run (see WithBinary), the
+//     gnuplot found in the PATH is run otherwise.
+//   - GO_GNUPLOT_TERM is the terminal to set (see WithTerminal).
+//
+// Example:
+//
+//	p, err := gnuplot.NewPlotterWithOptions(
+//	         gnuplot.WithPersist(),
+//	         gnuplot.WithMaxPoints(10000))
+//	if err != nil { /* handle error */ }
+//	defer p.Close()
+func NewPlotterWithOptions(opts ...Option) (*Plotter, error) {
+	p, err := newPlotter(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.dryRun {
+		proc, err := newPlotterProc(p.bin, p.persist)
 		if err != nil {
 			return nil, err
 		}
 		p.proc = proc
 	}
+	runtime.SetFinalizer(p, finalize)
+
+	if p.term != "" {
+		err := p.applyTerminal()
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+	}
 	return p, nil
 }
+
+// newPlotter creates a Plotter configured by `opts` and the environment,
+// see NewPlotterWithOptions, without starting its gnuplot subprocess.
+func newPlotter(opts ...Option) (*Plotter, error) {
+	p := &Plotter{proc: nil, debug: false, plotcmd: "plot",
+		nplots: 0, style: "points", closeTimeout: defaultCloseTimeout}
+	p.tmpfiles = make(tmpfilesDb)
+
+	for _, opt := range opts {
+		err := opt(p)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if p.term == "" {
+		p.term = os.Getenv("GO_GNUPLOT_TERM")
+	}
+	return p, nil
+}
+
+// PlotStructs will create a 2-d plot of the records `data`, a slice of
+// structs or of pointers to structs, using their fields named `xField` and
+// `yField` as the x- and y-coordinates, with `title` as the plot title.
+// The fields must hold numbers (integers or floats).
+// Example:
+//
+//	type Sample struct {
+//	    Time  float64
+//	    Value int
+//	}
+//	err = p.PlotStructs(samples, "Time", "Value", "my title")
+func (pltr *Plotter) PlotStructs(data interface{}, xField, yField, title string) error {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice {
+		return &gnuplotError{fmt.Sprintf("invalid records of type '%T': not a slice", data)}
+	}
+	x := make([]float64, v.Len())
+	y := make([]float64, v.Len())
+	for i := range x {
+		rec := reflect.Indirect(v.Index(i))
+		if rec.Kind() != reflect.Struct {
+			return &gnuplotError{fmt.Sprintf("invalid record %d of type '%v': not a struct",
+				i, v.Index(i).Type())}
+		}
+		var err error
+		x[i], err = numField(rec, xField)
+		if err != nil {
+			return err
+		}
+		y[i], err = numField(rec, yField)
+		if err != nil {
+			return err
+		}
+	}
+	return pltr.PlotXY(x, y, title)
+}
+
+// numField returns the value of the field `name` of the struct `rec`, which
+// must hold a number.
+func numField(rec reflect.Value, name string) (float64, error) {
+	f := rec.FieldByName(name)
+	if !f.IsValid() {
+		return 0, &gnuplotError{fmt.Sprintf("invalid field '%s': not a field of '%v'", name, rec.Type())}
+	}
+	switch f.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return f.Float(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(f.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(f.Uint()), nil
+	}
+	return 0, &gnuplotError{fmt.Sprintf("invalid field '%s' of type '%v': not a number", name, f.Type())}
+}
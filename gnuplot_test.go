@@ -0,0 +1,588 @@
+package gnuplot
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testDir holds the files written by the tests, it is removed once they
+// are done.
+var testDir string
+
+func TestMain(m *testing.M) {
+	var err error
+	testDir, err = ioutil.TempDir("", "go-gnuplot-test")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	code := m.Run()
+	os.RemoveAll(testDir)
+	os.Exit(code)
+}
+
+// fakeGnuplot writes a fake gnuplot binary running the shell command `cmd`
+// and returns its path.
+func fakeGnuplot(t *testing.T, cmd string) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+	dir, err := ioutil.TempDir(testDir, "bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin := filepath.Join(dir, "gnuplot")
+	err = ioutil.WriteFile(bin, []byte("#!/bin/sh\n"+cmd+"\n"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bin
+}
+
+// hungGnuplot writes a fake gnuplot binary which ignores its input and
+// never exits on its own, and returns its path.
+func hungGnuplot(t *testing.T) string {
+	return fakeGnuplot(t, "exec sleep 60")
+}
+
+func TestCloseHungProcess(t *testing.T) {
+	p, err := NewPlotterWithOptions(
+		WithBinary(hungGnuplot(t)),
+		WithCloseTimeout(100*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.PlotX([]float64{1, 2, 3}, "data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fnames []string
+	for fname := range p.tmpfiles {
+		fnames = append(fnames, fname)
+	}
+	if len(fnames) != 1 {
+		t.Fatalf("got %d data files, want 1", len(fnames))
+	}
+
+	start := time.Now()
+	err = p.Close()
+	if err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Fatalf("Close() = %v, want a timeout error", err)
+	}
+	if d := time.Since(start); d > 10*time.Second {
+		t.Errorf("Close took %v", d)
+	}
+	if p.Running() {
+		t.Error("subprocess still running after Close")
+	}
+	for _, fname := range fnames {
+		if _, err := os.Stat(fname); !os.IsNotExist(err) {
+			t.Errorf("data file %s not removed: %v", fname, err)
+		}
+	}
+}
+
+// newDryRunPlotter creates a Plotter in dry-run mode.
+func newDryRunPlotter(t *testing.T, opts ...Option) *Plotter {
+	p, err := NewPlotterWithOptions(append([]Option{WithDryRun()}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// dataFile returns the content of the only data file of `p`.
+func dataFile(t *testing.T, p *Plotter) string {
+	if len(p.tmpfiles) != 1 {
+		t.Fatalf("got %d data files, want 1", len(p.tmpfiles))
+	}
+	for fname := range p.tmpfiles {
+		buf, err := ioutil.ReadFile(fname)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(buf)
+	}
+	return ""
+}
+
+func TestDataPrecision(t *testing.T) {
+	p := newDryRunPlotter(t)
+	defer p.Close()
+	err := p.SetDataPrecision(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.PlotXY(
+		[]float64{1.23456, 2, 1234567},
+		[]float64{3.14159, 1e-7, -0.0009876},
+		"data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "1.23 3.14\n2 1e-07\n1.23e+06 -0.000988\n"
+	if got := dataFile(t, p); got != want {
+		t.Errorf("data file:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestDataFullPrecision(t *testing.T) {
+	p := newDryRunPlotter(t)
+	defer p.Close()
+	a, b := 0.1, 0.2
+	err := p.PlotXY([]float64{1}, []float64{a + b}, "data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "1 0.30000000000000004\n"
+	if got := dataFile(t, p); got != want {
+		t.Errorf("data file:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestEmptyData(t *testing.T) {
+	id := func(v float64) float64 { return v }
+	times := func(d []float64) []time.Time { return make([]time.Time, len(d)) }
+	labels := func(d []float64) []string { return make([]string, len(d)) }
+	cplx := func(d []float64) []complex128 { return make([]complex128, len(d)) }
+	tests := []struct {
+		name string
+		plot func(p *Plotter, d []float64) error
+		own  bool // whether the helper returns its own error rather than errNoData
+	}{
+		{"PlotNd/1", func(p *Plotter, d []float64) error { return p.PlotNd("", d) }, false},
+		{"PlotNd/2", func(p *Plotter, d []float64) error { return p.PlotNd("", d, d) }, false},
+		{"PlotNd/3", func(p *Plotter, d []float64) error { return p.PlotNd("", d, d, d) }, false},
+		{"PlotX", func(p *Plotter, d []float64) error { return p.PlotX(d, "") }, false},
+		{"PlotXY", func(p *Plotter, d []float64) error { return p.PlotXY(d, d, "") }, false},
+		{"PlotXYLine", func(p *Plotter, d []float64) error { return p.PlotXYLine(d, d, "") }, false},
+		{"PlotXYScatter", func(p *Plotter, d []float64) error { return p.PlotXYScatter(d, d, "") }, false},
+		{"PlotMapXY", func(p *Plotter, d []float64) error { return p.PlotMapXY(nil, "") }, false},
+		{"PlotXYAxes", func(p *Plotter, d []float64) error { return p.PlotXYAxes(d, d, "", "x1y2") }, false},
+		{"PlotXYSmooth", func(p *Plotter, d []float64) error { return p.PlotXYSmooth(d, d, "bezier", "") }, false},
+		{"PlotXYNormalized", func(p *Plotter, d []float64) error { return p.PlotXYNormalized(d, d, "") }, false},
+		{"PlotXYZ", func(p *Plotter, d []float64) error { return p.PlotXYZ(d, d, d, "") }, false},
+		{"PlotXYZScatter", func(p *Plotter, d []float64) error { return p.PlotXYZScatter(d, d, d, "") }, false},
+		{"PlotFunc", func(p *Plotter, d []float64) error { return p.PlotFunc(d, id, "") }, false},
+		{"PlotStructs", func(p *Plotter, d []float64) error {
+			return p.PlotStructs(make([]struct{ X, Y float64 }, len(d)), "X", "Y", "")
+		}, false},
+		{"AddSeries", func(p *Plotter, d []float64) error { return p.AddSeries(d, d, "") }, false},
+		{"PlotSpec.Draw", func(p *Plotter, d []float64) error { return p.NewPlot().Data(d, d).Draw() }, false},
+		{"PlotTimeSeries", func(p *Plotter, d []float64) error { return p.PlotTimeSeries(times(d), d, "") }, false},
+		{"PlotXYDuration", func(p *Plotter, d []float64) error {
+			return p.PlotXYDuration(d, make([]time.Duration, len(d)), time.Second, "")
+		}, false},
+		{"PlotBars", func(p *Plotter, d []float64) error { return p.PlotBars(labels(d), d, "") }, false},
+		{"PlotBarsGrouped", func(p *Plotter, d []float64) error {
+			return p.PlotBarsGrouped(labels(d), map[string][]float64{"a": d}, false)
+		}, false},
+		{"PlotCandlestick", func(p *Plotter, d []float64) error { return p.PlotCandlestick(d, d, d, d, d, "") }, false},
+		{"PlotCandlestickTime", func(p *Plotter, d []float64) error {
+			return p.PlotCandlestickTime(times(d), d, d, d, d, "")
+		}, false},
+		{"PlotFillBetween", func(p *Plotter, d []float64) error { return p.PlotFillBetween(d, d, d, "") }, false},
+		{"PlotBubble", func(p *Plotter, d []float64) error { return p.PlotBubble(d, d, d, "") }, false},
+		{"PlotBubbleColor", func(p *Plotter, d []float64) error {
+			return p.PlotBubbleColor(d, d, d, make([]float64, len(d)), "")
+		}, false},
+		{"PlotVectorField", func(p *Plotter, d []float64) error { return p.PlotVectorField(d, d, d, d, "") }, false},
+		{"PlotPolar", func(p *Plotter, d []float64) error { return p.PlotPolar(d, d, "") }, false},
+		{"PlotParametric", func(p *Plotter, d []float64) error { return p.PlotParametric(d, id, id, "") }, false},
+		{"PlotStem", func(p *Plotter, d []float64) error { return p.PlotStem(d, d, 0, "") }, false},
+		{"PlotStep", func(p *Plotter, d []float64) error { return p.PlotStep(d, d, "steps", "") }, false},
+		{"PlotLabels", func(p *Plotter, d []float64) error { return p.PlotLabels(d, d, labels(d), "") }, false},
+		{"PlotHistogramLog", func(p *Plotter, d []float64) error { return p.PlotHistogramLog(d, 10, "") }, false},
+		{"PlotComplexMag", func(p *Plotter, d []float64) error { return p.PlotComplexMag(cplx(d), "") }, false},
+		{"PlotComplexPhase", func(p *Plotter, d []float64) error { return p.PlotComplexPhase(cplx(d), "") }, false},
+		{"PlotArgand", func(p *Plotter, d []float64) error { return p.PlotArgand(cplx(d), "") }, false},
+		{"PlotHeatmap", func(p *Plotter, d []float64) error { return p.PlotHeatmap([][]float64{d}, "") }, true},
+		{"PlotBoxplot", func(p *Plotter, d []float64) error {
+			return p.PlotBoxplot(map[string][]float64{"a": d})
+		}, true},
+		{"PlotContour", func(p *Plotter, d []float64) error {
+			return p.PlotContour(d, d, func(x, y float64) float64 { return x * y }, 5)
+		}, true},
+		{"PlotSurface", func(p *Plotter, d []float64) error { return p.PlotSurface(d, d, nil, "") }, true},
+		{"PlotXYFit", func(p *Plotter, d []float64) error {
+			_, err := p.PlotXYFit(d, d, 1, "")
+			return err
+		}, true},
+	}
+	for _, tt := range tests {
+		for _, d := range [][]float64{nil, {}} {
+			p := newDryRunPlotter(t)
+			err := tt.plot(p, d)
+			switch {
+			case err == nil:
+				t.Errorf("%s(%#v) = nil, want an error", tt.name, d)
+			case !tt.own && err != errNoData:
+				t.Errorf("%s(%#v) = %v, want %v", tt.name, d, err, errNoData)
+			}
+			if len(p.tmpfiles) != 0 {
+				t.Errorf("%s(%#v) created %d data files", tt.name, d, len(p.tmpfiles))
+			}
+			if script := p.Script(); script != "" {
+				t.Errorf("%s(%#v) sent:\n%s", tt.name, d, script)
+			}
+			p.Close()
+		}
+	}
+}
+
+func TestPlotStructs(t *testing.T) {
+	type sample struct {
+		T int
+		V float32
+		S string
+	}
+	p := newDryRunPlotter(t)
+	defer p.Close()
+	err := p.PlotStructs([]*sample{{1, 0.5, "a"}, {2, 1.5, "b"}}, "T", "V", "data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "1 0.5\n2 1.5\n"
+	if got := dataFile(t, p); got != want {
+		t.Errorf("data file:\n%q\nwant:\n%q", got, want)
+	}
+
+	bad := []struct {
+		data           interface{}
+		xField, yField string
+	}{
+		{[]float64{1}, "T", "V"},
+		{sample{}, "T", "V"},
+		{[]*sample{nil}, "T", "V"},
+		{[]sample{{}}, "T", "W"},
+		{[]sample{{}}, "S", "V"},
+	}
+	for _, tt := range bad {
+		err := p.PlotStructs(tt.data, tt.xField, tt.yField, "")
+		if _, ok := err.(*gnuplotError); !ok {
+			t.Errorf("PlotStructs(%#v, %q, %q) = %v, want a gnuplotError",
+				tt.data, tt.xField, tt.yField, err)
+		}
+	}
+}
+
+func TestSetVarFloat(t *testing.T) {
+	p := newDryRunPlotter(t)
+	defer p.Close()
+	for _, v := range []float64{2, -3, 2.5, 1e21, 1e-7} {
+		err := p.SetVar("a", v)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := "a = 2.0\na = -3.0\na = 2.5\na = 1e+21\na = 1e-07\n"
+	if got := p.Script(); got != want {
+		t.Errorf("script:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestNaNAsGap(t *testing.T) {
+	p := newDryRunPlotter(t, WithNaNAsGap())
+	defer p.Close()
+	err := p.PlotXY(
+		[]float64{0, 1, 2, 3},
+		[]float64{1, math.NaN(), 3, 4},
+		"data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "0 1\n\n2 3\n3 4\n"
+	if got := dataFile(t, p); got != want {
+		t.Errorf("data file:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestNaNWithoutGap(t *testing.T) {
+	p := newDryRunPlotter(t)
+	defer p.Close()
+	err := p.PlotXY([]float64{0, 1}, []float64{1, math.NaN()}, "data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "0 1\n1 NaN\n"
+	if got := dataFile(t, p); got != want {
+		t.Errorf("data file:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestDeterministicTempNames(t *testing.T) {
+	dir, err := ioutil.TempDir(testDir, "data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var scripts []string
+	for run := 0; run < 2; run++ {
+		p, err := NewPlotterWithOptions(WithDryRun(), WithDeterministicTempNames(dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 2; i++ {
+			err = p.PlotX([]float64{1, 2, 3}, "data")
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		scripts = append(scripts, p.Script())
+		err = p.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := strings.Join([]string{
+		`plot "` + filepath.Join(dir, "go-gnuplot-1.dat") + `" title "data" with points`,
+		`replot "` + filepath.Join(dir, "go-gnuplot-2.dat") + `" title "data" with points`,
+		""}, "\n")
+	for run, got := range scripts {
+		if got != want {
+			t.Errorf("script of run %d:\n%s\nwant:\n%s", run, got, want)
+		}
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf("%d data files left in %s", len(files), dir)
+	}
+}
+
+func TestPause(t *testing.T) {
+	p := newDryRunPlotter(t)
+	defer p.Close()
+	for _, seconds := range []float64{-1, -0.5, math.NaN(), math.Inf(1)} {
+		if err := p.Pause(seconds, ""); err == nil {
+			t.Errorf("Pause(%v) = nil, want an error", seconds)
+		}
+	}
+	err := p.Pause(0.5, `next "frame"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.PauseMouse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "pause 0.5 \"next \\\"frame\\\"\"\npause mouse any\n"
+	if got := p.Script(); got != want {
+		t.Errorf("script:\n%s\nwant:\n%s", got, want)
+	}
+
+	for _, tt := range []struct {
+		seconds float64
+		want    time.Duration
+	}{
+		{0.5, 500*time.Millisecond + queryTimeout},
+		{1e10, math.MaxInt64},
+		{1e300, math.MaxInt64},
+	} {
+		if got := pauseTimeout(tt.seconds); got != tt.want {
+			t.Errorf("pauseTimeout(%v) = %v, want %v", tt.seconds, got, tt.want)
+		}
+	}
+}
+
+func TestPlotStreamThenPlotXY(t *testing.T) {
+	p := newDryRunPlotter(t)
+	defer p.Close()
+	ch := make(chan [2]float64)
+	stop, err := p.PlotStream(ch, "stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.PlotXY([]float64{1}, []float64{2}, "xy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch <- [2]float64{0, 1}
+	stop()
+
+	lines := strings.Split(strings.TrimSuffix(p.Script(), "\n"), "\n")
+	if len(lines) != 3 ||
+		!strings.HasPrefix(lines[0], "plot ") || !strings.Contains(lines[0], `title "stream"`) ||
+		!strings.HasPrefix(lines[1], "replot ") || !strings.Contains(lines[1], `title "xy"`) ||
+		lines[2] != "replot" {
+		t.Errorf("script:\n%s\nwant the stream plot, the xy replot and a redraw", p.Script())
+	}
+	if n := p.ActivePlots(); n != 2 {
+		t.Errorf("ActivePlots() = %d, want 2", n)
+	}
+}
+
+func TestFormatQuoting(t *testing.T) {
+	p := newDryRunPlotter(t)
+	defer p.Close()
+	err := p.SetAxisFormat("y", `%.1f "units"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.SetTimeFmt(`%H"%M`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.PlotTimeSeries([]time.Time{time.Unix(0, 0)}, []float64{1}, "data",
+		TimeFormat("%d\"%m\n%Y"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(p.Script(), "\n")
+	want := []string{
+		`set format y "%.1f \"units\""`,
+		`set timefmt "%H\"%M"`,
+		`set xdata time`,
+		`set timefmt "%Y-%m-%dT%H:%M:%S"`,
+		`set format x "%d\"%m\n%Y"`,
+	}
+	if len(lines) < len(want) || strings.Join(lines[:len(want)], "\n") != strings.Join(want, "\n") {
+		t.Errorf("script:\n%s\nwant it to start with:\n%s", p.Script(), strings.Join(want, "\n"))
+	}
+}
+
+func TestSetPaletteDefinedInvalid(t *testing.T) {
+	p := newDryRunPlotter(t)
+	defer p.Close()
+	for _, stop := range []PaletteStop{
+		{math.NaN(), "red"},
+		{math.Inf(1), "red"},
+		{1, "red' front"},
+		{1, "#12345"},
+	} {
+		err := p.SetPaletteDefined(PaletteStop{0, "blue"}, stop)
+		if _, ok := err.(*gnuplotError); !ok {
+			t.Errorf("SetPaletteDefined(%v) = %v, want a gnuplotError", stop, err)
+		}
+	}
+	if script := p.Script(); script != "" {
+		t.Errorf("sent:\n%s", script)
+	}
+}
+
+func TestTitleQuoting(t *testing.T) {
+	p := newDryRunPlotter(t)
+	defer p.Close()
+	const title = `a "b" \c`
+	const quoted = `"a \"b\" \\c`
+	err := p.PlotXY([]float64{1}, []float64{2}, title)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.PlotBarsGrouped([]string{"x"}, map[string][]float64{title: {1}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.PlotXYFit([]float64{1, 2}, []float64{1, 2}, 1, title)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := p.Script()
+	for _, want := range []string{
+		" title " + quoted + `" with points`,
+		" title " + quoted + `" with histograms`,
+		" title " + quoted + ` fit" with lines`,
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script:\n%s\nwant it to contain:\n%s", script, want)
+		}
+	}
+}
+
+func BenchmarkPlotXY1M(b *testing.B) {
+	x := make([]float64, 1000000)
+	y := make([]float64, len(x))
+	for i := range x {
+		x[i] = float64(i)
+		y[i] = math.Sin(float64(i) / 1000)
+	}
+	p, err := NewPlotterWithOptions(WithDryRun())
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer p.Close()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err = p.PlotXY(x, y, "data")
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.StopTimer()
+		p.ResetPlot()
+		b.StartTimer()
+	}
+}
+
+func TestAnnotationsNotFinite(t *testing.T) {
+	p := newDryRunPlotter(t)
+	defer p.Close()
+	for _, v := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		if err := p.AddLabel("label", v, 0); err == nil {
+			t.Errorf("AddLabel(x=%v) = nil, want an error", v)
+		}
+		if err := p.AddLabel("label", 0, v); err == nil {
+			t.Errorf("AddLabel(y=%v) = nil, want an error", v)
+		}
+		if err := p.AddArrow(0, 0, v, 1); err == nil {
+			t.Errorf("AddArrow(x2=%v) = nil, want an error", v)
+		}
+	}
+	if script := p.Script(); script != "" {
+		t.Errorf("sent:\n%s", script)
+	}
+}
+
+func TestTimingCallbackSkipsPlumbing(t *testing.T) {
+	var cmds []string
+	p := newDryRunPlotter(t, WithTimingCallback(func(cmd string, d time.Duration) {
+		cmds = append(cmds, cmd)
+	}))
+	defer p.Close()
+	err := p.Cmd("set grid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.plumbing("print \"marker\"")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cmds) != 1 || cmds[0] != "set grid" {
+		t.Errorf("timed %q, want only \"set grid\"", cmds)
+	}
+}
+
+func TestSetTicsEmpty(t *testing.T) {
+	p := newDryRunPlotter(t)
+	defer p.Close()
+	for _, positions := range [][]float64{nil, {}} {
+		err := p.SetXTics(positions, nil)
+		if _, ok := err.(*gnuplotError); !ok {
+			t.Errorf("SetXTics(%#v, nil) = %v, want a gnuplotError", positions, err)
+		}
+	}
+	if script := p.Script(); script != "" {
+		t.Errorf("sent:\n%s", script)
+	}
+}
+
+func TestComplexNotFinite(t *testing.T) {
+	p := newDryRunPlotter(t)
+	defer p.Close()
+	err := p.PlotArgand([]complex128{
+		complex(math.NaN(), math.Inf(1)),
+		complex(math.Inf(-1), 0),
+		complex(0, math.NaN()),
+		1 + 2i,
+	}, "data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "\n\n\n1 2\n"
+	if got := dataFile(t, p); got != want {
+		t.Errorf("data file:\n%q\nwant:\n%q", got, want)
+	}
+}
@@ -0,0 +1,87 @@
+// Package gnuplottest provides helpers to test code producing plots with
+// package gnuplot.
+package gnuplottest
+
+import (
+	"flag"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/ckitagawa/go-gnuplot"
+)
+
+// update tells RenderAndCompare to rewrite the golden files instead of
+// comparing them, eg. with: go test -update-golden
+var update = flag.Bool("update-golden", false, "update the golden files of gnuplottest")
+
+// Size of the deterministic rendering, in characters.
+const (
+	goldenWidth  = 80
+	goldenHeight = 25
+)
+
+// RenderAndCompare renders the active plots of `p` as text, with gnuplot's
+// dumb terminal which output doesn't depend on the date or the fonts
+// installed, and fails `t` if the result differs from the golden file at
+// `goldenPath`.
+// The plots without a title are left out of the key while rendering, since
+// gnuplot would title them with the random names of their data files: the
+// automatic titles are turned back on afterwards ('set key autotitle', the
+// default of gnuplot).
+// Running the tests with the -update-golden flag rewrites the golden files
+// with the current rendering instead.
+// Example:
+//  func TestPlot(t *testing.T) {
+//      p, err := gnuplot.NewPlotter("", false, false)
+//      ...
+//      err = p.PlotX([]float64{1, 4, 9}, "squares")
+//      gnuplottest.RenderAndCompare(t, p, "testdata/squares.txt")
+//  }
+func RenderAndCompare(t testing.TB, p *gnuplot.Plotter, goldenPath string) {
+	err := p.Cmd("set key noautotitle")
+	if err != nil {
+		t.Fatalf("setting key: %v", err)
+	}
+	got, err := p.RenderText(goldenWidth, goldenHeight)
+	kerr := p.Cmd("set key autotitle")
+	if err != nil {
+		t.Fatalf("rendering plot: %v", err)
+	}
+	if kerr != nil {
+		t.Fatalf("restoring key: %v", kerr)
+	}
+
+	if *update {
+		err = ioutil.WriteFile(goldenPath, []byte(got), 0644)
+		if err != nil {
+			t.Fatalf("updating golden file: %v", err)
+		}
+		return
+	}
+
+	buf, err := ioutil.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("reading golden file: %v", err)
+	}
+	want := string(buf)
+	if got == want {
+		return
+	}
+	gotLines := strings.Split(got, "\n")
+	wantLines := strings.Split(want, "\n")
+	for i := 0; i < len(gotLines) || i < len(wantLines); i++ {
+		var g, w string
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if g != w {
+			t.Errorf("plot differs from golden file %s at line %d:\ngot:  %q\nwant: %q",
+				goldenPath, i+1, g, w)
+			return
+		}
+	}
+}
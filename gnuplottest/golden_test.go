@@ -0,0 +1,122 @@
+package gnuplottest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/ckitagawa/go-gnuplot"
+)
+
+// fakeGnuplot is a fake gnuplot binary rendering every plot as "rendered"
+// and answering the markers of sync.
+const fakeGnuplot = `#!/bin/sh
+while IFS= read -r line; do
+	case "$line" in
+	'set output "'*) out=${line#set output \"}; out=${out%\"} ;;
+	'unset output') out= ;;
+	replot) [ -n "$out" ] && echo rendered >"$out" ;;
+	'set print "'*) marker=${line#set print \"}; marker=${marker%\"} ;;
+	'print "ok"') echo ok >"$marker" ;;
+	esac
+done
+`
+
+// recorder is a testing.TB recording the failures reported to it.
+type recorder struct {
+	*testing.T
+	errors []string
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// newPlotter returns a Plotter using fakeGnuplot, with a plot to render, and
+// a directory for the golden files, to be removed.
+func newPlotter(t *testing.T) (*gnuplot.Plotter, string) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+	dir, err := ioutil.TempDir("", "gnuplottest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin := filepath.Join(dir, "gnuplot")
+	err = ioutil.WriteFile(bin, []byte(fakeGnuplot), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := gnuplot.NewPlotterWithOptions(gnuplot.WithBinary(bin), gnuplot.WithScript())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.PlotX([]float64{1, 4, 9}, "squares")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, dir
+}
+
+func TestRenderAndCompareMatch(t *testing.T) {
+	p, dir := newPlotter(t)
+	defer os.RemoveAll(dir)
+	defer p.Close()
+	golden := filepath.Join(dir, "golden.txt")
+	err := ioutil.WriteFile(golden, []byte("rendered\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &recorder{T: t}
+	RenderAndCompare(r, p, golden)
+	if len(r.errors) != 0 {
+		t.Errorf("RenderAndCompare reported %q for a matching golden file", r.errors)
+	}
+	if script := p.Script(); !strings.HasSuffix(script, "set key autotitle\n") {
+		t.Errorf("script:\n%s\nwant the automatic titles turned back on", script)
+	}
+}
+
+func TestRenderAndCompareMismatch(t *testing.T) {
+	p, dir := newPlotter(t)
+	defer os.RemoveAll(dir)
+	defer p.Close()
+	golden := filepath.Join(dir, "golden.txt")
+	err := ioutil.WriteFile(golden, []byte("something else\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &recorder{T: t}
+	RenderAndCompare(r, p, golden)
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], "at line 1") {
+		t.Errorf("RenderAndCompare reported %q, want a difference at line 1", r.errors)
+	}
+}
+
+func TestRenderAndCompareUpdate(t *testing.T) {
+	p, dir := newPlotter(t)
+	defer os.RemoveAll(dir)
+	defer p.Close()
+	golden := filepath.Join(dir, "golden.txt")
+
+	*update = true
+	defer func() { *update = false }()
+	r := &recorder{T: t}
+	RenderAndCompare(r, p, golden)
+	if len(r.errors) != 0 {
+		t.Errorf("RenderAndCompare reported %q while updating", r.errors)
+	}
+	buf, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(buf); got != "rendered\n" {
+		t.Errorf("golden file %q, want %q", got, "rendered\n")
+	}
+}
@@ -0,0 +1,166 @@
+package gnuplot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// PlotConfig describes a whole 2-d plot, see PlotFromJSON.
+type PlotConfig struct {
+	Title  string         `json:"title"`  // plot title
+	XLabel string         `json:"xlabel"` // label of the x-axis
+	YLabel string         `json:"ylabel"` // label of the y-axis
+	Series []SeriesConfig `json:"series"` // data series, at least one
+}
+
+// SeriesConfig describes a data series of a PlotConfig. Only `x` and `y`
+// are mandatory and must have the same, non-zero, length, the other fields
+// are the same as the ones of PlotSpec.
+type SeriesConfig struct {
+	Title     string    `json:"title"`
+	X         []float64 `json:"x"`
+	Y         []float64 `json:"y"`
+	Style     string    `json:"style"`     // defaults to the current style
+	Color     string    `json:"color"`     // eg. "red" or "#ff0000"
+	LineWidth float64   `json:"linewidth"` // 0 for the default width
+	Axes      string    `json:"axes"`      // eg. "x1y2"
+}
+
+// validate returns an error describing the first invalid setting of the
+// configuration, if any.
+func (cfg *PlotConfig) validate() error {
+	if len(cfg.Series) == 0 {
+		return &gnuplotError{"invalid plot config: no series"}
+	}
+	for i, s := range cfg.Series {
+		if len(s.X) != len(s.Y) {
+			return &gnuplotError{fmt.Sprintf(
+				"invalid plot config: series %d has %d x for %d y values",
+				i, len(s.X), len(s.Y))}
+		}
+		if len(s.X) == 0 {
+			return &gnuplotError{fmt.Sprintf(
+				"invalid plot config: series %d has no data points", i)}
+		}
+		if s.Style != "" && !validStyle(s.Style) {
+			return &gnuplotError{fmt.Sprintf(
+				"invalid plot config: series %d has invalid style '%s'", i, s.Style)}
+		}
+		if s.Axes != "" && checkAxes(s.Axes) != nil {
+			return &gnuplotError{fmt.Sprintf(
+				"invalid plot config: series %d has invalid axes '%s'", i, s.Axes)}
+		}
+		if s.LineWidth < 0 {
+			return &gnuplotError{fmt.Sprintf(
+				"invalid plot config: series %d has invalid line width '%v'",
+				i, s.LineWidth)}
+		}
+	}
+	return nil
+}
+
+// checkFields returns an error for the first key of the JSON PlotConfig
+// `data`, or of one of its series, that matches none of their fields.
+func checkFields(data []byte) error {
+	var cfg struct {
+		Series []json.RawMessage `json:"series"`
+	}
+	err := json.Unmarshal(data, &cfg)
+	if err != nil {
+		return err
+	}
+	err = checkKeys(data, reflect.TypeOf(PlotConfig{}))
+	for i := 0; err == nil && i < len(cfg.Series); i++ {
+		err = checkKeys(cfg.Series[i], reflect.TypeOf(SeriesConfig{}))
+	}
+	return err
+}
+
+// checkKeys returns an error for the first key, in sorted order, of the
+// JSON object `data` that matches none of the json tags of the struct type
+// `typ`. Keys are matched without regard to case, as json.Unmarshal does.
+func checkKeys(data []byte, typ reflect.Type) error {
+	var obj map[string]json.RawMessage
+	err := json.Unmarshal(data, &obj)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		known := false
+		for i := 0; i < typ.NumField() && !known; i++ {
+			known = strings.EqualFold(key, typ.Field(i).Tag.Get("json"))
+		}
+		if !known {
+			return fmt.Errorf("json: unknown field %q", key)
+		}
+	}
+	return nil
+}
+
+// PlotFromJSON reads a PlotConfig in JSON from `r` and plots it: the title
+// and labels are set and all the series are drawn with a single fresh plot
+// command. The whole configuration is validated before anything is sent to
+// gnuplot.
+// Example:
+//  err = p.PlotFromJSON(strings.NewReader(`{
+//    "title": "my title",
+//    "xlabel": "x",
+//    "series": [
+//      {"title": "squares", "x": [0, 1, 2], "y": [0, 1, 4], "style": "lines"},
+//      {"title": "cubes", "x": [0, 1, 2], "y": [0, 1, 8], "color": "red"}
+//    ]}`))
+func (pltr *Plotter) PlotFromJSON(r io.Reader) error {
+	var cfg PlotConfig
+	data, err := ioutil.ReadAll(r)
+	if err == nil {
+		err = json.Unmarshal(data, &cfg)
+	}
+	if err == nil {
+		err = checkFields(data)
+	}
+	if err != nil {
+		return &gnuplotError{fmt.Sprintf("invalid plot config: %v", err)}
+	}
+	err = cfg.validate()
+	if err != nil {
+		return err
+	}
+
+	cmds := []string{"set title " + quote(cfg.Title)}
+	if cfg.XLabel != "" {
+		cmds = append(cmds, "set xlabel "+quote(cfg.XLabel))
+	}
+	if cfg.YLabel != "" {
+		cmds = append(cmds, "set ylabel "+quote(cfg.YLabel))
+	}
+	err = pltr.cmds(cmds...)
+	if err != nil {
+		return err
+	}
+
+	elems := make([]string, 0, len(cfg.Series))
+	for _, s := range cfg.Series {
+		spec := pltr.NewPlot().Data(s.X, s.Y).Title(s.Title).
+			Color(s.Color).LineWidth(s.LineWidth).Axes(s.Axes)
+		if s.Style != "" {
+			spec.Style(s.Style)
+		}
+		elem, err := spec.element()
+		if err != nil {
+			return err
+		}
+		elems = append(elems, elem)
+	}
+	pltr.nplots = len(elems)
+	return pltr.Cmd("%s %s", pltr.plotcmd, strings.Join(elems, ", "))
+}
@@ -0,0 +1,74 @@
+package gnuplot
+
+import (
+	"fmt"
+)
+
+// LineStyleOpts are the settings of a line style defined with
+// DefineLineStyle. The zero value of a field keeps the gnuplot default.
+type LineStyleOpts struct {
+	Type      int     // line type
+	Color     string  // line color, eg. "red" or "#ff0000"
+	Width     float64 // line width
+	PointType int     // point type, see SetPointType
+	PointSize float64 // point size
+}
+
+// DefineLineStyle defines the line style `index` (from 1) with the settings
+// `opts`, to be referenced by the plots with UseLineStyle or
+// PlotSpec.LineStyle, eg. to share a theme across all the series of a
+// figure.
+// Example:
+//  err = p.DefineLineStyle(1, gnuplot.LineStyleOpts{
+//           Color: "#0060ad", Width: 2, PointType: 7, PointSize: 1.5})
+//  err = p.UseLineStyle(1)
+func (pltr *Plotter) DefineLineStyle(index int, opts LineStyleOpts) error {
+	if index <= 0 {
+		return &gnuplotError{fmt.Sprintf("invalid line style index '%v'", index)}
+	}
+	if opts.Type < 0 || opts.Width < 0 || opts.PointSize < 0 {
+		return &gnuplotError{fmt.Sprintf("invalid line style '%+v'", opts)}
+	}
+	if opts.PointType < 0 || opts.PointType > maxPointType {
+		return &gnuplotError{fmt.Sprintf("invalid point type '%v'", opts.PointType)}
+	}
+
+	line := fmt.Sprintf("set style line %d", index)
+	if opts.Type > 0 {
+		line += fmt.Sprintf(" linetype %d", opts.Type)
+	}
+	if opts.Color != "" {
+		line += " linecolor rgb " + quote(opts.Color)
+	}
+	if opts.Width > 0 {
+		line += fmt.Sprintf(" linewidth %v", opts.Width)
+	}
+	if opts.PointType > 0 {
+		line += fmt.Sprintf(" pointtype %d", opts.PointType)
+	}
+	if opts.PointSize > 0 {
+		line += fmt.Sprintf(" pointsize %v", opts.PointSize)
+	}
+
+	err := pltr.Cmd("%s", line)
+	if err != nil {
+		return err
+	}
+	if pltr.lineStyles == nil {
+		pltr.lineStyles = make(map[int]bool)
+	}
+	pltr.lineStyles[index] = true
+	return nil
+}
+
+// UseLineStyle makes the subsequent plots use the line style `index`,
+// defined with DefineLineStyle. 0 stops using a line style.
+// Example:
+//  err = p.UseLineStyle(2)
+func (pltr *Plotter) UseLineStyle(index int) error {
+	if index != 0 && !pltr.lineStyles[index] {
+		return &gnuplotError{fmt.Sprintf("undefined line style '%v'", index)}
+	}
+	pltr.linestyle = index
+	return nil
+}
@@ -0,0 +1,189 @@
+package gnuplot
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Option is an option of NewPlotterWithOptions.
+type Option func(*Plotter) error
+
+// WithPersist runs the gnuplot subprocess with '-persist' so the plot window
+// isn't closed after sending a command.
+func WithPersist() Option {
+	return func(p *Plotter) error {
+		p.persist = true
+		return nil
+	}
+}
+
+// WithDebug tells go-gnuplot to print out every command sent to the gnuplot
+// subprocess.
+func WithDebug() Option {
+	return func(p *Plotter) error {
+		p.debug = true
+		return nil
+	}
+}
+
+// WithMaxPoints limits to `n` the number of points written to the data files
+// of PlotX, PlotXY, PlotXYZ, PlotFunc, PlotTimeSeries and their variants.
+// Larger data sets are decimated in Go, before being written, by keeping
+// every k-th point only, with k the smallest step giving at most `n` points.
+// This keeps data files small and gnuplot responsive with huge data sets.
+func WithMaxPoints(n int) Option {
+	return func(p *Plotter) error {
+		if n <= 0 {
+			return &gnuplotError{fmt.Sprintf("invalid max number of points '%v'", n)}
+		}
+		p.maxPoints = n
+		return nil
+	}
+}
+
+// WithCloseTimeout sets how long Close waits for the gnuplot subprocess to
+// exit before killing it. It defaults to 5 seconds.
+func WithCloseTimeout(d time.Duration) Option {
+	return func(p *Plotter) error {
+		if d <= 0 {
+			return &gnuplotError{fmt.Sprintf("invalid close timeout '%v'", d)}
+		}
+		p.closeTimeout = d
+		return nil
+	}
+}
+
+// WithCommandLog copies every command sent to the gnuplot subprocess to `w`,
+// eg. to keep a reproducible script of the plots. The internal commands of
+// the Plotter are left out, as in Script.
+func WithCommandLog(w io.Writer) Option {
+	return func(p *Plotter) error {
+		if w == nil {
+			return &gnuplotError{"nil command log"}
+		}
+		p.cmdLog = w
+		return nil
+	}
+}
+
+// WithScript keeps the commands sent to the gnuplot subprocess in memory,
+// to be retrieved with Script, eg. to dump them when debugging a plot. They
+// are kept until ResetAll, so prefer WithCommandLog for long-lived Plotters.
+func WithScript() Option {
+	return func(p *Plotter) error {
+		p.scripted = true
+		return nil
+	}
+}
+
+// WithBinary runs the gnuplot binary at `path`, rather than the one given by
+// the GO_GNUPLOT_BIN environment variable or found in the PATH.
+func WithBinary(path string) Option {
+	return func(p *Plotter) error {
+		if path == "" {
+			return &gnuplotError{"empty gnuplot binary path"}
+		}
+		p.bin = path
+		return nil
+	}
+}
+
+// WithTerminal sets the terminal `term` with the options `opts` on creation,
+// rather than the one given by the GO_GNUPLOT_TERM environment variable or
+// gnuplot's default terminal. See SetTerminal.
+func WithTerminal(term string, opts ...string) Option {
+	return func(p *Plotter) error {
+		if strings.TrimSpace(term) == "" {
+			return &gnuplotError{"empty terminal"}
+		}
+		p.term = term
+		p.termOpts = opts
+		return nil
+	}
+}
+
+// WithStyle sets the initial plotting style to `style` rather than "points".
+// See SetStyle for the allowed styles.
+func WithStyle(style string) Option {
+	return func(p *Plotter) error {
+		return p.SetStyle(style)
+	}
+}
+
+// WithTimingCallback calls `fn` after each write to the gnuplot subprocess
+// with the commands written, without their trailing newline, and how long
+// the write took, eg. to find the plots dominating the render time.
+// Commands sent in a single write, such as the ones of CmdBatch, are
+// reported together, one per line. The internal commands of the Plotter,
+// such as the reply markers of Query, are not reported.
+// Writes only wait for gnuplot once its input buffer is full: to time the
+// full processing of the commands, follow them with Ping.
+// `fn` is called from the goroutine sending the commands and may use the
+// Plotter.
+func WithTimingCallback(fn func(cmd string, d time.Duration)) Option {
+	return func(p *Plotter) error {
+		if fn == nil {
+			return &gnuplotError{"nil timing callback"}
+		}
+		p.timing = fn
+		return nil
+	}
+}
+
+// WithKeepTempFiles keeps the data files written by the plotting helpers
+// after ResetPlot and Close instead of removing them, eg. to inspect them
+// when debugging a broken plot. The paths of the kept files are printed.
+func WithKeepTempFiles() Option {
+	return func(p *Plotter) error {
+		p.keepTmpfiles = true
+		return nil
+	}
+}
+
+// WithNaNAsGap makes PlotXY, and its PlotXYLine and PlotXYScatter variants,
+// write a blank line in place of the points with a NaN y-coordinate, so that
+// gnuplot breaks the line there, leaving a gap, instead of connecting the
+// surrounding points. This only makes sense for the styles drawing lines,
+// such as "lines", "linespoints" and the steps styles.
+func WithNaNAsGap() Option {
+	return func(p *Plotter) error {
+		p.nanAsGap = true
+		return nil
+	}
+}
+
+// WithDryRun creates a Plotter without a gnuplot subprocess: the commands
+// are only recorded, to be retrieved with Script (or copied with
+// WithCommandLog), eg. to unit test code using the Plotter on machines
+// without gnuplot. The data files are written as usual.
+// The functions needing the replies of gnuplot, such as Query, return an
+// error, and the rendering functions return empty outputs.
+func WithDryRun() Option {
+	return func(p *Plotter) error {
+		p.dryRun = true
+		p.scripted = true
+		return nil
+	}
+}
+
+// WithDeterministicTempNames names the data files written by the plotting
+// helpers, and the files rendered by RenderText and RenderBytes,
+// "go-gnuplot-1.dat", "go-gnuplot-2.dat", "go-gnuplot-3.out", ... by order of
+// creation, in the existing directory `dir` (the default temporary directory
+// if empty), rather than with random names, so that the scripts of different
+// runs (see Script) are the same. The numbers of the files already present in
+// `dir`, such as the ones of another Plotter, are skipped. The files are
+// removed as usual.
+func WithDeterministicTempNames(dir string) Option {
+	return func(p *Plotter) error {
+		if dir == "" {
+			dir = os.TempDir()
+		}
+		p.seqNames = true
+		p.seqDir = dir
+		return nil
+	}
+}
@@ -0,0 +1,84 @@
+package gnuplot
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// palettes maps the palette names accepted by SetPalette to the gnuplot
+// palette definition they stand for.
+var palettes = map[string]string{
+	"gray":    "gray",
+	"rgb":     "rgbformulae 7,5,15",
+	"rainbow": "rgbformulae 33,13,10",
+	"hot":     "rgbformulae 21,22,23",
+	"ocean":   "rgbformulae 23,28,3",
+	"viridis": "defined (0 '#440154', 1 '#472c7a', 2 '#3b518b', " +
+		"3 '#2c718e', 4 '#21908d', 5 '#27ad81', 6 '#5cc863', " +
+		"7 '#aadc32', 8 '#fde725')",
+}
+
+// SetPalette changes the palette (colormap) used by pm3d and heatmap plots.
+// Only known palette names are accepted:
+//    "gray",
+//    "rgb",
+//    "rainbow",
+//    "hot",
+//    "ocean",
+//    "viridis"
+// Use SetPaletteDefined to define a palette from color stops.
+func (pltr *Plotter) SetPalette(name string) error {
+	palette, ok := palettes[name]
+	if !ok {
+		names := make([]string, 0, len(palettes))
+		for n := range palettes {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return &gnuplotError{fmt.Sprintf(
+			"invalid palette '%s' (allowed: %v)", name, names)}
+	}
+	return pltr.Cmd("set palette %s", palette)
+}
+
+// PaletteStop is a color stop of a palette defined with SetPaletteDefined.
+// `Pos` is the position of the stop on the color scale and `Color` a color
+// name such as "red" or a "#rrggbb" or "#aarrggbb" hexadecimal color.
+type PaletteStop struct {
+	Pos   float64
+	Color string
+}
+
+// SetPaletteDefined changes the palette to the one interpolated between the
+// color stops `stops`. The positions of the stops must be in increasing order.
+// Example:
+//  err = p.SetPaletteDefined(
+//           gnuplot.PaletteStop{Pos: 0, Color: "blue"},
+//           gnuplot.PaletteStop{Pos: 1, Color: "white"},
+//           gnuplot.PaletteStop{Pos: 2, Color: "red"})
+func (pltr *Plotter) SetPaletteDefined(stops ...PaletteStop) error {
+	if len(stops) < 2 {
+		return &gnuplotError{fmt.Sprintf(
+			"invalid number of palette stops '%v'", len(stops))}
+	}
+
+	defs := make([]string, 0, len(stops))
+	for i, stop := range stops {
+		if math.IsNaN(stop.Pos) || math.IsInf(stop.Pos, 0) {
+			return &gnuplotError{fmt.Sprintf("invalid palette stop position '%v'", stop.Pos)}
+		}
+		if i > 0 && stop.Pos < stops[i-1].Pos {
+			return &gnuplotError{"palette stops are not in increasing order"}
+		}
+		if stop.Color == "" {
+			return &gnuplotError{fmt.Sprintf("empty color for palette stop %d", i)}
+		}
+		if !colorSpec.MatchString(stop.Color) {
+			return &gnuplotError{fmt.Sprintf("invalid palette stop color '%s'", stop.Color)}
+		}
+		defs = append(defs, fmt.Sprintf("%v '%s'", stop.Pos, stop.Color))
+	}
+	return pltr.Cmd("set palette defined (%s)", strings.Join(defs, ", "))
+}
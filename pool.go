@@ -0,0 +1,151 @@
+package gnuplot
+
+import (
+	"fmt"
+	"sync"
+)
+
+// PlotterPool keeps a set of warm Plotters for reuse, saving the startup of
+// a gnuplot subprocess per plot in high-throughput services.
+// A PlotterPool is safe for concurrent use.
+type PlotterPool struct {
+	mu     sync.Mutex
+	idle   []*Plotter
+	size   int      // maximum number of idle Plotters
+	opts   []Option // options of the new Plotters
+	closed bool
+
+	defaults *Plotter // settings given by opts, restored by Put
+}
+
+// NewPlotterPool creates a pool keeping up to `size` idle Plotters, created
+// with the options `opts` (see NewPlotterWithOptions).
+// Example:
+//  pool, err := gnuplot.NewPlotterPool(4, gnuplot.WithTerminal("pngcairo"))
+//  if err != nil { /* handle error */ }
+//  defer pool.Close()
+//
+//  p, err := pool.Get()
+//  if err != nil { /* handle error */ }
+//  defer pool.Put(p)
+func NewPlotterPool(size int, opts ...Option) (*PlotterPool, error) {
+	if size <= 0 {
+		return nil, &gnuplotError{fmt.Sprintf("invalid pool size '%v'", size)}
+	}
+	defaults, err := newPlotter(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &PlotterPool{size: size, opts: opts, defaults: defaults}, nil
+}
+
+// Get returns an idle Plotter of the pool, or a new one if there is none.
+func (pool *PlotterPool) Get() (*Plotter, error) {
+	pool.mu.Lock()
+	if pool.closed {
+		pool.mu.Unlock()
+		return nil, &gnuplotError{"plotter pool is closed"}
+	}
+	for len(pool.idle) > 0 {
+		p := pool.idle[len(pool.idle)-1]
+		pool.idle = pool.idle[:len(pool.idle)-1]
+		if reusable(p) {
+			pool.mu.Unlock()
+			return p, nil
+		}
+		p.Close()
+	}
+	pool.mu.Unlock()
+	return NewPlotterWithOptions(pool.opts...)
+}
+
+// Put returns the Plotter `p`, obtained with Get, to the pool. Its plots,
+// gnuplot settings and script are reset with ResetAll, and the settings given by
+// the options of the pool (style, terminal, ...) are restored. The Plotter
+// is closed instead if the pool is full or closed, or if it can't be reset,
+// eg. when its terminal was changed and the pool has no terminal to restore.
+// Terminals set with raw commands (see Cmd) are not restored.
+// `p` must not be used after Put.
+func (pool *PlotterPool) Put(p *Plotter) {
+	if p == nil {
+		return
+	}
+	if !reusable(p) || pool.reset(p) != nil {
+		p.Close()
+		return
+	}
+
+	pool.mu.Lock()
+	if pool.closed || len(pool.idle) >= pool.size {
+		pool.mu.Unlock()
+		p.Close()
+		return
+	}
+	pool.idle = append(pool.idle, p)
+	pool.mu.Unlock()
+}
+
+// reusable reports whether the Plotter `p` can be handed out again: its
+// subprocess is still running, or it has none since it is in dry-run mode.
+func reusable(p *Plotter) bool {
+	return p.dryRun || p.Running()
+}
+
+// reset restores the Plotter `p` to the state of a new Plotter of the pool,
+// see Put.
+func (pool *PlotterPool) reset(p *Plotter) error {
+	if p.output != "" {
+		err := p.CloseOutput()
+		if err != nil {
+			return err
+		}
+	}
+	err := p.ResetAll()
+	if err != nil {
+		return err
+	}
+
+	d := pool.defaults
+	if d.term == "" && p.term != "" {
+		// gnuplot can't restore its startup terminal.
+		return &gnuplotError{"can't restore the default terminal"}
+	}
+	p.plotcmd = d.plotcmd
+	p.style = d.style
+	p.pointType = d.pointType
+	p.dashType = d.dashType
+	p.maxPoints = d.maxPoints
+	p.precision = d.precision
+	p.nanAsGap = d.nanAsGap
+	p.appendOutput = d.appendOutput
+	p.term = d.term
+	p.termOpts = d.termOpts
+	p.width, p.height = d.width, d.height
+	p.font = d.font
+	p.dpi = d.dpi
+	p.transparent = d.transparent
+	p.background = d.background
+	if p.term == "" {
+		return nil
+	}
+	return p.applyTerminal()
+}
+
+// Close closes the idle Plotters of the pool. The Plotters returned with
+// Put afterwards are closed right away.
+func (pool *PlotterPool) Close() error {
+	pool.mu.Lock()
+	idle := pool.idle
+	pool.idle = nil
+	pool.closed = true
+	pool.mu.Unlock()
+
+	var err error
+	for _, p := range idle {
+		cerr := p.Close()
+		if cerr != nil {
+			err = cerr
+		}
+	}
+	return err
+}
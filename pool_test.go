@@ -0,0 +1,110 @@
+package gnuplot
+
+import (
+	"testing"
+)
+
+func TestPoolPutRestoresDefaults(t *testing.T) {
+	pool, err := NewPlotterPool(1,
+		WithBinary(fakeGnuplot(t, "exec cat >/dev/null")),
+		WithTerminal("pngcairo"),
+		WithStyle("lines"),
+		WithScript())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	p, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range []error{
+		p.SetStyle("impulses"),
+		p.SetPointType(5),
+		p.SetDataPrecision(3),
+		p.SetTerminal("pdf"),
+		p.SetSize(4, 3),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	pool.Put(p)
+
+	q, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	if q != p {
+		t.Fatal("Get didn't return the pooled Plotter")
+	}
+	if q.style != "lines" || q.pointType != 0 || q.precision != 0 {
+		t.Errorf("got style %q, point type %d and precision %d, want \"lines\", 0 and 0",
+			q.style, q.pointType, q.precision)
+	}
+	if q.term != "pngcairo" || q.width != 0 || q.height != 0 {
+		t.Errorf("got terminal %q of size %dx%d, want \"pngcairo\" of default size",
+			q.term, q.width, q.height)
+	}
+	want := "set terminal pngcairo\n"
+	if got := q.Script(); got != want {
+		t.Errorf("script:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPoolPutClosesUnrestorable(t *testing.T) {
+	pool, err := NewPlotterPool(1, WithBinary(fakeGnuplot(t, "exec cat >/dev/null")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	p, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.SetTerminal("pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.Put(p)
+	if p.Running() {
+		t.Error("Plotter with a changed terminal kept in the pool")
+	}
+}
+
+func TestPoolReusesDryRun(t *testing.T) {
+	pool, err := NewPlotterPool(1, WithDryRun(), WithTerminal("pngcairo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	p, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.PlotX([]float64{1, 2, 3}, "data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.Put(p)
+
+	q, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	if q != p {
+		t.Fatal("Get didn't return the pooled dry-run Plotter")
+	}
+	if q.ActivePlots() != 0 {
+		t.Errorf("got %d active plots, want 0", q.ActivePlots())
+	}
+	want := "set terminal pngcairo\n"
+	if got := q.Script(); got != want {
+		t.Errorf("script:\n%s\nwant:\n%s", got, want)
+	}
+}
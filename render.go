@@ -0,0 +1,214 @@
+package gnuplot
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// syncTimeout is how long sync waits for gnuplot to catch up.
+const syncTimeout = 10 * time.Second
+
+// sync blocks until gnuplot has processed all the commands sent so far.
+// gnuplot is asked to print a marker to a file, which is only written once
+// the previous commands are done.
+func (pltr *Plotter) sync() error {
+	if pltr.dryRun {
+		return nil
+	}
+	f, err := ioutil.TempFile(os.TempDir(), gnuplotPrefix)
+	if err != nil {
+		return err
+	}
+	marker := f.Name()
+	f.Close()
+	defer os.Remove(marker)
+
+	err = pltr.plumbing(
+		"set print "+quote(marker),
+		"print \"ok\"",
+		"unset print")
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(syncTimeout)
+	for time.Now().Before(deadline) {
+		buf, err := ioutil.ReadFile(marker)
+		if err == nil && strings.TrimSpace(string(buf)) == "ok" {
+			return nil
+		}
+		if !pltr.Running() {
+			return errExited
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return &gnuplotError{"timeout waiting for gnuplot"}
+}
+
+// render replots the active plots with the terminal `term` into a temporary
+// file and returns its content. The previous terminal is restored afterwards,
+// but not the previous output: gnuplot can't save it, and setting it again
+// would truncate the file.
+func (pltr *Plotter) render(term string) ([]byte, error) {
+	if pltr.nplots == 0 {
+		return nil, &gnuplotError{"no active plot to render"}
+	}
+	f, err := pltr.createTmpfile(".out")
+	if err != nil {
+		return nil, err
+	}
+	fname := f.Name()
+	f.Close()
+	defer os.Remove(fname)
+
+	// Setting the output closes the one left open by SaveToFile.
+	pltr.output = ""
+	err = pltr.transient(
+		"set terminal push",
+		"set terminal "+term,
+		"set output "+quote(fname),
+		"replot",
+		"unset output",
+		"set terminal pop")
+	if err != nil {
+		return nil, err
+	}
+	err = pltr.sync()
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(fname)
+}
+
+// RenderText renders the active plots as ASCII art of `width` x `height`
+// characters, using gnuplot's dumb terminal.
+// The current terminal is left untouched, but the current output is closed
+// and unset: set it again before plotting to a file.
+// Example:
+//  txt, err := p.RenderText(80, 25)
+func (pltr *Plotter) RenderText(width, height int) (string, error) {
+	if width <= 0 || height <= 0 {
+		return "", &gnuplotError{fmt.Sprintf(
+			"invalid text size '%dx%d'", width, height)}
+	}
+	buf, err := pltr.render(fmt.Sprintf("dumb size %d,%d", width, height))
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// RenderBytes renders the active plots with the terminal `term` and its
+// options `opts`, eg. "pdfcairo", "svg" or "pngcairo", and returns the
+// output, eg. to serve it from a web service. The output file is closed and
+// complete by the time RenderBytes returns.
+// The current terminal is left untouched, but the current output is closed
+// and unset: set it again before plotting to a file.
+// Example:
+//  svg, err := p.RenderBytes("svg", "size 800,600")
+func (pltr *Plotter) RenderBytes(term string, opts ...string) ([]byte, error) {
+	if strings.TrimSpace(term) == "" {
+		return nil, &gnuplotError{"empty terminal"}
+	}
+	if len(opts) > 0 {
+		term += " " + strings.Join(opts, " ")
+	}
+	return pltr.render(term)
+}
+
+// RenderTo is the same as RenderBytes but writes the output to `w`, eg. an
+// http.ResponseWriter. The errors of `w` are returned.
+// Example:
+//  w.Header().Set("Content-Type", "image/svg+xml")
+//  err = p.RenderTo(w, "svg", "size 800,600")
+func (pltr *Plotter) RenderTo(w io.Writer, term string, opts ...string) error {
+	if w == nil {
+		return &gnuplotError{"nil writer"}
+	}
+	buf, err := pltr.RenderBytes(term, opts...)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+// CloseOutput closes the output file of the current terminal, set with
+// 'set output' or left open by SaveToFile in append mode, and waits for
+// gnuplot to be done writing it.
+// gnuplot may only flush the output file when it is closed, or when another
+// output or terminal is set, so reading the file before CloseOutput may
+// yield partial data.
+func (pltr *Plotter) CloseOutput() error {
+	pltr.output = ""
+	err := pltr.transient("unset output")
+	if err != nil {
+		return err
+	}
+	return pltr.sync()
+}
+
+// SetOutputAppend turns the append mode of SaveToFile on or off.
+// In append mode, the output file is left open after saving, and saving
+// again to the same file adds the active plots as a new page, eg. for
+// paginated reports with the pdf, pdfcairo or postscript terminals. Other
+// terminals don't support pages: they overwrite the file or produce an
+// invalid one. The file is complete once closed by CloseOutput, by saving
+// to another file, by turning the append mode off or by Close. RenderText
+// and RenderBytes close it too, so saving again would overwrite it.
+// Example:
+//  err = p.SetTerminal("pdfcairo")
+//  err = p.SetOutputAppend(true)
+//  err = p.SaveToFile("report.pdf") // page 1
+//  err = p.ResetPlot()
+//  err = p.PlotX([]float64{1, 2, 3}, "page 2")
+//  err = p.SaveToFile("report.pdf") // page 2
+//  err = p.CloseOutput()
+func (pltr *Plotter) SetOutputAppend(on bool) error {
+	pltr.appendOutput = on
+	if !on && pltr.output != "" {
+		return pltr.CloseOutput()
+	}
+	return nil
+}
+
+// SaveToFile saves the active plots to the file `path`, with the current
+// terminal (see SetTerminal). The file is closed with CloseOutput, so it is
+// complete by the time SaveToFile returns, unless the append mode is on, see
+// SetOutputAppend.
+// Example:
+//  err = p.SetTerminal("pngcairo")
+//  err = p.SaveToFile("plot.png")
+func (pltr *Plotter) SaveToFile(path string) error {
+	if pltr.nplots == 0 {
+		return &gnuplotError{"no active plot to save"}
+	}
+	if path == "" {
+		return &gnuplotError{"empty output path"}
+	}
+	if !pltr.appendOutput {
+		err := pltr.transient("set output "+quote(path), "replot")
+		if err != nil {
+			return err
+		}
+		return pltr.CloseOutput()
+	}
+
+	var err error
+	if path == pltr.output {
+		// Add a page to the open output.
+		err = pltr.transient("replot")
+	} else {
+		// Setting the new output closes the previous one.
+		err = pltr.transient("set output "+quote(path), "replot")
+	}
+	if err != nil {
+		return err
+	}
+	pltr.output = path
+	return pltr.sync()
+}
@@ -0,0 +1,195 @@
+package gnuplot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PlotSpec is a builder for a single 2-d data series. It carries its own
+// style, color, line width and axes so that they don't have to be set
+// globally on the Plotter.
+// A PlotSpec is created with Plotter.NewPlot and sent with Draw:
+//  err = p.NewPlot().
+//           Data([]float64{0, 1, 2}, []float64{0, 1, 4}).
+//           Title("my title").
+//           Style("lines").
+//           Color("red").
+//           LineWidth(2).
+//           Axes("x1y2").
+//           Draw()
+type PlotSpec struct {
+	pltr  *Plotter
+	x, y  []float64
+	title string
+	style string
+	color string
+	lw    float64
+	ls    int
+	axes  string
+}
+
+// NewPlot creates a new PlotSpec, using the current style of the Plotter as
+// its default style.
+func (pltr *Plotter) NewPlot() *PlotSpec {
+	return &PlotSpec{pltr: pltr, style: pltr.style}
+}
+
+// Data sets the x- and y-coordinates of the series. If the lengths of the
+// slices do not match, the range for the data will be the smallest size of
+// the two slices.
+func (s *PlotSpec) Data(x, y []float64) *PlotSpec {
+	s.x, s.y = x, y
+	return s
+}
+
+// Title sets the title of the series.
+func (s *PlotSpec) Title(title string) *PlotSpec {
+	s.title = title
+	return s
+}
+
+// Style sets the plotting style of the series, see SetStyle for the allowed
+// styles.
+func (s *PlotSpec) Style(style string) *PlotSpec {
+	s.style = style
+	return s
+}
+
+// Color sets the line color of the series, eg. "red" or "#ff0000".
+func (s *PlotSpec) Color(color string) *PlotSpec {
+	s.color = color
+	return s
+}
+
+// LineWidth sets the line width of the series.
+func (s *PlotSpec) LineWidth(width float64) *PlotSpec {
+	s.lw = width
+	return s
+}
+
+// LineStyle sets the line style of the series to the line style `index`
+// defined with DefineLineStyle.
+func (s *PlotSpec) LineStyle(index int) *PlotSpec {
+	s.ls = index
+	return s
+}
+
+// Axes sets the pair of axes the series is plotted against, one of "x1y1",
+// "x1y2", "x2y1" or "x2y2".
+func (s *PlotSpec) Axes(axes string) *PlotSpec {
+	s.axes = axes
+	return s
+}
+
+// with returns the style specification of the series, validating its
+// settings.
+func (s *PlotSpec) with() (string, error) {
+	if !validStyle(s.style) {
+		return "", &gnuplotError{fmt.Sprintf("invalid style '%s'", s.style)}
+	}
+	ls := s.pltr.linestyle
+	if s.ls != 0 {
+		if !s.pltr.lineStyles[s.ls] {
+			return "", &gnuplotError{fmt.Sprintf("undefined line style '%v'", s.ls)}
+		}
+		ls = s.ls
+	}
+	with := s.pltr.lineStyle(s.style, ls)
+	if s.color != "" {
+		with += " linecolor rgb " + quote(s.color)
+	}
+	if s.lw < 0 {
+		return "", &gnuplotError{fmt.Sprintf("invalid line width '%v'", s.lw)}
+	}
+	if s.lw > 0 {
+		with += fmt.Sprintf(" linewidth %v", s.lw)
+	}
+	return with, nil
+}
+
+// element writes the data of the series and returns its plot element.
+func (s *PlotSpec) element() (string, error) {
+	with, err := s.with()
+	if err != nil {
+		return "", err
+	}
+	mods := ""
+	if s.axes != "" {
+		err = checkAxes(s.axes)
+		if err != nil {
+			return "", err
+		}
+		mods = "axes " + s.axes
+	}
+	npoints := min(len(s.x), len(s.y))
+	if npoints == 0 {
+		return "", errNoData
+	}
+
+	fname, err := s.pltr.writeTmpfile(func(w *dataWriter) {
+		step := s.pltr.stride(npoints)
+		for i := 0; i < npoints; i += step {
+			w.Printf("%v %v\n", s.x[i], s.y[i])
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return plotElement(fname, mods, s.title, with), nil
+}
+
+// Draw writes the data of the series and sends the plot command.
+func (s *PlotSpec) Draw() error {
+	elem, err := s.element()
+	if err != nil {
+		return err
+	}
+
+	cmd := s.pltr.plotcmd
+	if s.pltr.nplots > 0 {
+		cmd = "replot"
+	}
+	s.pltr.nplots++
+	return s.pltr.Cmd("%s %s", cmd, elem)
+}
+
+// Stage writes the data of the series and stages it for the next Render of
+// the Plotter, instead of plotting it right away.
+func (s *PlotSpec) Stage() error {
+	elem, err := s.element()
+	if err != nil {
+		return err
+	}
+	s.pltr.staged = append(s.pltr.staged, elem)
+	return nil
+}
+
+// AddSeries stages the series (`x`, `y`) with `title` as its title and the
+// current style for the next Render. See PlotSpec.Stage for series with
+// their own style options.
+// Example:
+//  err = p.AddSeries([]float64{0, 1, 2}, []float64{0, 1, 4}, "squares")
+//  err = p.AddSeries([]float64{0, 1, 2}, []float64{0, 1, 8}, "cubes")
+//  err = p.Render()
+func (pltr *Plotter) AddSeries(x, y []float64, title string) error {
+	return pltr.NewPlot().Data(x, y).Title(title).Stage()
+}
+
+// Render draws all the staged series at once, with a single fresh plot
+// command replacing the active plots instead of overlaying them.
+// The series stay staged, so that more can be added and rendered again,
+// until ClearSeries or ResetPlot is called.
+func (pltr *Plotter) Render() error {
+	if len(pltr.staged) == 0 {
+		return &gnuplotError{"no staged series to render"}
+	}
+	pltr.nplots = len(pltr.staged)
+	return pltr.Cmd("%s %s", pltr.plotcmd, strings.Join(pltr.staged, ", "))
+}
+
+// ClearSeries removes all the staged series. Their data files are removed
+// by ResetPlot.
+func (pltr *Plotter) ClearSeries() {
+	pltr.staged = nil
+}
@@ -0,0 +1,80 @@
+package gnuplot
+
+import (
+	"sync"
+	"time"
+)
+
+// streamRefresh is the minimum delay between two redraws of a streamed plot.
+const streamRefresh = 200 * time.Millisecond
+
+// PlotStream will create a 2-d plot of the (x, y) points received on `ch`,
+// with `title` as the plot title, redrawing it as new points arrive.
+// Points are appended to a data file as they come and the plot is redrawn
+// at most every 200ms, however fast the points arrive, so that a slow
+// gnuplot isn't flooded with redraws. The plot command is sent right away,
+// so that the plots added before the first point arrives are drawn along
+// with the stream, and gnuplot may warn about the empty data file until
+// then.
+// Streaming stops when `ch` is closed or when the returned `stop` function
+// is called, which waits for the streaming goroutine to be done. The Plotter
+// may be used concurrently for other commands while streaming, but stop
+// must be called before ResetPlot or Close.
+// Example:
+//  ch := make(chan [2]float64)
+//  stop, err := p.PlotStream(ch, "my title")
+//  if err != nil { /* handle error */ }
+//  defer stop()
+//  ch <- [2]float64{0, 1}
+func (pltr *Plotter) PlotStream(ch <-chan [2]float64, title string) (stop func(), err error) {
+	f, err := pltr.newTmpfile()
+	if err != nil {
+		return nil, err
+	}
+	err = pltr.plotData(pltr.plotcmd, f.Name(), "", title, pltr.style)
+	if err != nil {
+		return nil, err
+	}
+
+	quit := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		// The file is left open for ResetPlot to close.
+		defer close(finished)
+
+		w := pltr.newDataWriter(f)
+		ticker := time.NewTicker(streamRefresh)
+		defer ticker.Stop()
+		pending := false
+		redraw := func() {
+			if !pending || w.Flush() != nil {
+				return
+			}
+			pltr.transient("replot")
+			pending = false
+		}
+		for {
+			select {
+			case pt, ok := <-ch:
+				if !ok {
+					redraw()
+					return
+				}
+				w.Printf("%v %v\n", pt[0], pt[1])
+				pending = true
+			case <-ticker.C:
+				redraw()
+			case <-quit:
+				redraw()
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	stop = func() {
+		once.Do(func() { close(quit) })
+		<-finished
+	}
+	return stop, nil
+}
@@ -0,0 +1,338 @@
+package gnuplot
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// vectorTerminals lists the terminals whose size is expressed in inches
+// rather than in pixels.
+var vectorTerminals = map[string]bool{
+	"pdf":        true,
+	"pdfcairo":   true,
+	"postscript": true,
+	"epscairo":   true,
+	"epslatex":   true,
+	"cairolatex": true,
+}
+
+// rasterTerminals lists the terminals producing bitmaps, whose size is
+// scaled by SetDPI.
+var rasterTerminals = map[string]bool{
+	"png":      true,
+	"pngcairo": true,
+	"jpeg":     true,
+	"gif":      true,
+}
+
+// transparentTerminals lists the terminals supporting a transparent
+// background.
+var transparentTerminals = map[string]bool{
+	"png":      true,
+	"pngcairo": true,
+	"gif":      true,
+}
+
+const (
+	// baseDPI is the resolution the pixel sizes are given for.
+	baseDPI = 96
+
+	// defaultRasterWidth and defaultRasterHeight are the default pixel size
+	// of the raster terminals of gnuplot.
+	defaultRasterWidth  = 640
+	defaultRasterHeight = 480
+)
+
+// applyTerminal sends the 'set terminal' command for the current terminal
+// and its settings.
+func (pltr *Plotter) applyTerminal() error {
+	line := "set terminal " + pltr.term
+	width, height := pltr.width, pltr.height
+	if rasterTerminals[pltr.term] && pltr.dpi > 0 {
+		if width == 0 || height == 0 {
+			width, height = defaultRasterWidth, defaultRasterHeight
+		}
+		width = width * pltr.dpi / baseDPI
+		height = height * pltr.dpi / baseDPI
+	}
+	if width > 0 && height > 0 {
+		if vectorTerminals[pltr.term] {
+			line += fmt.Sprintf(" size %din,%din", width, height)
+		} else {
+			line += fmt.Sprintf(" size %d,%d", width, height)
+		}
+	}
+	if pltr.font != "" {
+		line += " font " + quote(pltr.font)
+	}
+	if pltr.transparent && transparentTerminals[pltr.term] {
+		line += " transparent"
+	}
+	if pltr.background != "" {
+		line += " background rgb " + quote(pltr.background)
+	}
+	if len(pltr.termOpts) > 0 {
+		line += " " + strings.Join(pltr.termOpts, " ")
+	}
+	return pltr.Cmd("%s", line)
+}
+
+// SetTerminal changes the gnuplot terminal (output format) to `term`, eg.
+// "png", "pdf" or "wxt", with the terminal specific options `opts`.
+// The size set with SetSize, the resolution set with SetDPI and the font set
+// with SetFont are applied to the terminal.
+// Example:
+//  err = p.SetTerminal("png", "enhanced")
+func (pltr *Plotter) SetTerminal(term string, opts ...string) error {
+	if strings.TrimSpace(term) == "" {
+		return &gnuplotError{"empty terminal"}
+	}
+	pltr.term = term
+	pltr.termOpts = opts
+	return pltr.applyTerminal()
+}
+
+// SetSize changes the size of the output to `width` x `height`, in pixels
+// for raster terminals (png, svg, ...) and in inches for vector terminals
+// (pdf, postscript, ...).
+// The size is applied by SetTerminal, or right away if a terminal was
+// already set with it.
+// Example:
+//  err = p.SetSize(800, 600)
+//  err = p.SetTerminal("png")
+func (pltr *Plotter) SetSize(width, height int) error {
+	if width <= 0 || height <= 0 {
+		return &gnuplotError{fmt.Sprintf("invalid size '%dx%d'", width, height)}
+	}
+	pltr.width = width
+	pltr.height = height
+	if pltr.term != "" {
+		return pltr.applyTerminal()
+	}
+	return nil
+}
+
+// SetDPI changes the resolution of the output of the raster terminals
+// (png, pngcairo, jpeg and gif) to `dpi` dots per inch, by scaling their
+// pixel size: the size set with SetSize, or the 640x480 default size, is
+// taken as the size at 96 DPI. Other terminals are not affected.
+// The resolution is applied by SetTerminal, or right away if a terminal was
+// already set with it.
+// Example:
+//  err = p.SetDPI(300)
+//  err = p.SetTerminal("png")
+func (pltr *Plotter) SetDPI(dpi int) error {
+	if dpi <= 0 {
+		return &gnuplotError{fmt.Sprintf("invalid DPI '%v'", dpi)}
+	}
+	pltr.dpi = dpi
+	if pltr.term != "" {
+		return pltr.applyTerminal()
+	}
+	return nil
+}
+
+// SetFont changes the default font of the texts of the plot to the font
+// family `name` at `size` points.
+// Fonts being terminal specific, the font is applied by SetTerminal, or
+// right away if a terminal was already set with it.
+// Example:
+//  err = p.SetFont("Helvetica", 12)
+func (pltr *Plotter) SetFont(name string, size float64) error {
+	if !(size > 0) {
+		return &gnuplotError{fmt.Sprintf("invalid font size '%v'", size)}
+	}
+	pltr.font = fmt.Sprintf("%s,%v", name, size)
+	if pltr.term != "" {
+		return pltr.applyTerminal()
+	}
+	return nil
+}
+
+// SetTransparent turns the transparent background of the png, pngcairo and
+// gif terminals on or off, eg. to composite the plots onto web pages. Other
+// terminals are not affected.
+// The setting is applied by SetTerminal, or right away if a terminal was
+// already set with it.
+// Example:
+//  err = p.SetTransparent(true)
+//  err = p.SetTerminal("pngcairo")
+func (pltr *Plotter) SetTransparent(on bool) error {
+	pltr.transparent = on
+	if pltr.term != "" {
+		return pltr.applyTerminal()
+	}
+	return nil
+}
+
+// colorSpec matches the colors accepted by SetBackgroundColor: a color name
+// or a "#rrggbb" or "#aarrggbb" hexadecimal color.
+var colorSpec = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9-]*|#[0-9A-Fa-f]{6}|#[0-9A-Fa-f]{8})$`)
+
+// SetBackgroundColor changes the background color of the output to `color`,
+// a color name such as "white" or a "#rrggbb" hexadecimal color. Terminals
+// without the background option, such as the dumb one, reject it.
+// An empty `color` restores the default background.
+// The color is applied by SetTerminal, or right away if a terminal was
+// already set with it.
+// Example:
+//  err = p.SetBackgroundColor("#f0f0f0")
+//  err = p.SetTerminal("pngcairo")
+func (pltr *Plotter) SetBackgroundColor(color string) error {
+	if color != "" && !colorSpec.MatchString(color) {
+		return &gnuplotError{fmt.Sprintf("invalid color '%s'", color)}
+	}
+	pltr.background = color
+	if pltr.term != "" {
+		return pltr.applyTerminal()
+	}
+	return nil
+}
+
+// encodings lists the character encodings accepted by SetEncoding.
+var encodings = []string{
+	"default",
+	"utf8",
+	"iso_8859_1",
+	"iso_8859_2",
+	"iso_8859_9",
+	"iso_8859_15",
+	"koi8r",
+	"koi8u",
+	"cp437",
+	"cp850",
+	"cp852",
+	"cp950",
+	"cp1250",
+	"cp1251",
+	"cp1252",
+	"cp1254",
+	"sjis"}
+
+// SetEncoding changes the character encoding used by gnuplot for the texts
+// of the plot, eg. "utf8" for labels with non-ASCII characters such as "°"
+// or "µ". Go strings being UTF-8, "utf8" is the encoding to use unless the
+// texts were converted.
+// Only the encodings known to gnuplot are accepted:
+//    "default", "utf8",
+//    "iso_8859_1", "iso_8859_2", "iso_8859_9", "iso_8859_15",
+//    "koi8r", "koi8u",
+//    "cp437", "cp850", "cp852", "cp950",
+//    "cp1250", "cp1251", "cp1252", "cp1254",
+//    "sjis"
+func (pltr *Plotter) SetEncoding(enc string) error {
+	for _, e := range encodings {
+		if e == enc {
+			return pltr.Cmd("set encoding %s", enc)
+		}
+	}
+	return &gnuplotError{fmt.Sprintf("invalid encoding '%s'", enc)}
+}
+
+// interactiveTerminals lists the terminals drawing in a window.
+var interactiveTerminals = map[string]bool{
+	"wxt":     true,
+	"qt":      true,
+	"x11":     true,
+	"aqua":    true,
+	"windows": true,
+}
+
+// WaitForWindow blocks until the user closes the plot window, which is
+// useful to keep a command line tool alive while its plot is displayed.
+// It returns right away if the current terminal isn't an interactive one
+// (wxt, qt, x11, aqua or windows).
+func (pltr *Plotter) WaitForWindow() error {
+	term := pltr.term
+	if term == "" {
+		reply, err := pltr.Query("print GPVAL_TERM")
+		if err != nil {
+			return err
+		}
+		term = strings.TrimSpace(reply)
+	}
+	if !interactiveTerminals[term] {
+		return nil
+	}
+	_, err := pltr.query("pause mouse close", 0)
+	return err
+}
+
+// AvailableTerminals returns the names of the terminals compiled into the
+// gnuplot binary, as listed by 'set terminal', eg. to use "pngcairo" if
+// available and fall back to "png" otherwise. The list is queried once and
+// cached.
+func (pltr *Plotter) AvailableTerminals() ([]string, error) {
+	if pltr.terminals == nil {
+		reply, err := pltr.Query("set terminal")
+		if err != nil {
+			return nil, err
+		}
+		terms := []string{}
+		listing := false
+		for _, line := range strings.Split(reply, "\n") {
+			if strings.HasPrefix(strings.TrimSpace(line), "Available terminal types") {
+				listing = true
+				continue
+			}
+			fields := strings.Fields(line)
+			if listing && len(fields) > 0 {
+				terms = append(terms, fields[0])
+			}
+		}
+		if len(terms) == 0 {
+			return nil, &gnuplotError{"no terminal listed by gnuplot"}
+		}
+		pltr.terminals = terms
+	}
+	return append([]string(nil), pltr.terminals...), nil
+}
+
+// Pause makes gnuplot wait for `seconds` seconds, printing `message` if not
+// empty, eg. between the frames of an animation, and blocks until gnuplot
+// resumes, so that the data files of the current plots can be safely reset.
+// Negative durations are rejected: gnuplot's -1 waits for a carriage return
+// on its input, which would swallow the next command sent by the Plotter.
+// Use PauseMouse to wait for the user of an interactive terminal instead.
+// Example:
+//  err = p.Pause(0.5, "")
+func (pltr *Plotter) Pause(seconds float64, message string) error {
+	if !(seconds >= 0) || math.IsInf(seconds, 0) {
+		return &gnuplotError{fmt.Sprintf("invalid pause duration '%v'", seconds)}
+	}
+	line := fmt.Sprintf("pause %v", seconds)
+	if message != "" {
+		line += " " + quote(message)
+	}
+	if pltr.dryRun {
+		return pltr.transient(line)
+	}
+	_, err := pltr.query(line, pauseTimeout(seconds))
+	return err
+}
+
+// pauseTimeout returns how long Pause waits for gnuplot to resume after a
+// pause of `seconds` seconds. It saturates for the pauses of centuries which
+// don't fit in a time.Duration.
+func pauseTimeout(seconds float64) time.Duration {
+	timeout := time.Duration(math.MaxInt64)
+	if d := seconds * float64(time.Second); d < float64(timeout-queryTimeout) {
+		timeout = time.Duration(d) + queryTimeout
+	}
+	return timeout
+}
+
+// PauseMouse makes gnuplot wait for a mouse click or a key press in the
+// window of an interactive terminal, and blocks until gnuplot resumes.
+// It returns right away for the other terminals.
+func (pltr *Plotter) PauseMouse() error {
+	const line = "pause mouse any"
+	if pltr.dryRun {
+		return pltr.transient(line)
+	}
+	_, err := pltr.query(line, 0)
+	return err
+}
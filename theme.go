@@ -0,0 +1,122 @@
+package gnuplot
+
+import (
+	"fmt"
+)
+
+// Theme bundles the settings giving figures a consistent look, applied in
+// one go with ApplyTheme. The zero value of a field keeps the current
+// setting.
+type Theme struct {
+	Background string          // background color, see SetBackgroundColor
+	Foreground string          // color of the border, tics and texts
+	Font       string          // font family, see SetFont
+	FontSize   float64         // font size, in points
+	Grid       bool            // whether to draw a grid
+	GridColor  string          // color of the grid lines
+	Palette    string          // palette name, see SetPalette
+	LineStyles []LineStyleOpts // defined as the line styles 1, 2, ...
+}
+
+// DefaultTheme is a light theme with a grid and well-distinguished series
+// colors.
+var DefaultTheme = Theme{
+	Background: "white",
+	Foreground: "black",
+	Grid:       true,
+	GridColor:  "#d0d0d0",
+	Palette:    "viridis",
+	LineStyles: []LineStyleOpts{
+		{Color: "#0072bd", Width: 2, PointType: 7},
+		{Color: "#d95319", Width: 2, PointType: 5},
+		{Color: "#edb120", Width: 2, PointType: 9},
+		{Color: "#7e2f8e", Width: 2, PointType: 11},
+	},
+}
+
+// DarkTheme is a dark theme, eg. for slides with a dark background.
+var DarkTheme = Theme{
+	Background: "#202020",
+	Foreground: "#e0e0e0",
+	Grid:       true,
+	GridColor:  "#505050",
+	Palette:    "hot",
+	LineStyles: []LineStyleOpts{
+		{Color: "#4dbeee", Width: 2, PointType: 7},
+		{Color: "#ff7f0e", Width: 2, PointType: 5},
+		{Color: "#a2d729", Width: 2, PointType: 9},
+		{Color: "#f564a9", Width: 2, PointType: 11},
+	},
+}
+
+// ApplyTheme applies the settings of the theme `t`: the background and the
+// font of the terminal first, then the colors of the border, tics and
+// texts, the grid, the palette and the line styles.
+// The line styles are referenced by the plots with UseLineStyle or
+// PlotSpec.LineStyle.
+// Example:
+//  err = p.ApplyTheme(gnuplot.DarkTheme)
+//  err = p.UseLineStyle(1)
+func (pltr *Plotter) ApplyTheme(t Theme) error {
+	if t.Background != "" {
+		err := pltr.SetBackgroundColor(t.Background)
+		if err != nil {
+			return err
+		}
+	}
+	if t.Font != "" {
+		size := t.FontSize
+		if size == 0 {
+			size = 10
+		}
+		err := pltr.SetFont(t.Font, size)
+		if err != nil {
+			return err
+		}
+	}
+
+	cmds := []string{}
+	if t.Foreground != "" {
+		if !colorSpec.MatchString(t.Foreground) {
+			return &gnuplotError{fmt.Sprintf("invalid color '%s'", t.Foreground)}
+		}
+		color := "rgb " + quote(t.Foreground)
+		cmds = append(cmds,
+			"set border linecolor "+color,
+			"set tics textcolor "+color,
+			"set key textcolor "+color,
+			"set title textcolor "+color,
+			"set xlabel textcolor "+color,
+			"set ylabel textcolor "+color)
+	}
+	if t.Grid {
+		grid := "set grid"
+		if t.GridColor != "" {
+			if !colorSpec.MatchString(t.GridColor) {
+				return &gnuplotError{fmt.Sprintf("invalid color '%s'", t.GridColor)}
+			}
+			grid += " linecolor rgb " + quote(t.GridColor)
+		}
+		cmds = append(cmds, grid)
+	}
+	if len(cmds) > 0 {
+		err := pltr.cmds(cmds...)
+		if err != nil {
+			return err
+		}
+	}
+
+	if t.Palette != "" {
+		err := pltr.SetPalette(t.Palette)
+		if err != nil {
+			return err
+		}
+	}
+	for i, ls := range t.LineStyles {
+		err := pltr.DefineLineStyle(i+1, ls)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
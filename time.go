@@ -0,0 +1,143 @@
+package gnuplot
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// timeLayout is the layout of the timestamps written to data files and
+	// timeFmt the matching gnuplot 'timefmt'. The fractional seconds, if any,
+	// are kept: gnuplot reads them with %S.
+	timeLayout string = "2006-01-02T15:04:05.999999999"
+	timeFmt    string = "%Y-%m-%dT%H:%M:%S"
+
+	// defaultTimeDisplayFormat is the default format of the time tic labels.
+	defaultTimeDisplayFormat string = "%Y-%m-%d\n%H:%M"
+)
+
+// formatTime formats `t` for a data file, in the time zone `loc`.
+func formatTime(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format(timeLayout)
+}
+
+// setTimeAxis switches `axis` to time data, reading the timestamps written by
+// formatTime and displaying the tic labels with the strftime-like `format`.
+func (pltr *Plotter) setTimeAxis(axis, format string) error {
+	return pltr.cmds(
+		fmt.Sprintf("set %sdata time", axis),
+		"set timefmt "+quote(timeFmt),
+		fmt.Sprintf("set format %s %s", axis, quote(format)))
+}
+
+// TimeOption is an option of the time series plots.
+type TimeOption func(*timeOptions)
+
+type timeOptions struct {
+	format string         // display format of the time tic labels
+	loc    *time.Location // time zone the timestamps are displayed in
+}
+
+// TimeFormat sets the strftime-like format used to display the time tic
+// labels, eg. "%H:%M:%S". It defaults to "%Y-%m-%d\n%H:%M".
+func TimeFormat(format string) TimeOption {
+	return func(o *timeOptions) {
+		o.format = format
+	}
+}
+
+// TimeLocation sets the time zone the timestamps are displayed in. It
+// defaults to UTC.
+func TimeLocation(loc *time.Location) TimeOption {
+	return func(o *timeOptions) {
+		o.loc = loc
+	}
+}
+
+// PlotTimeSeries will create a 2-d plot using the timestamps `t` as
+// x-coordinates, the matching values of `y` as y-coordinates and `title` as
+// the plot title.
+// If the lengths of the slices do not match, the range for the data will be
+// the smallest size of the two slices.
+// The x-axis is switched to time data, which is kept for subsequent plots.
+// Example:
+//  err = p.PlotTimeSeries(
+//           []time.Time{t1, t2, t3},
+//           []float64{11, 22, 33},
+//           "my title",
+//           gnuplot.TimeFormat("%H:%M"),
+//           gnuplot.TimeLocation(time.Local))
+func (pltr *Plotter) PlotTimeSeries(t []time.Time, y []float64, title string, opts ...TimeOption) error {
+	o := timeOptions{format: defaultTimeDisplayFormat, loc: time.UTC}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.loc == nil {
+		return &gnuplotError{"nil time location"}
+	}
+	npoints := min(len(t), len(y))
+	if npoints == 0 {
+		return errNoData
+	}
+
+	fname, err := pltr.writeTmpfile(func(w *dataWriter) {
+		step := pltr.stride(npoints)
+		for i := 0; i < npoints; i += step {
+			w.Printf("%s %v\n", formatTime(t[i], o.loc), y[i])
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = pltr.setTimeAxis("x", o.format)
+	if err != nil {
+		return err
+	}
+	return pltr.plotData(pltr.plotcmd, fname, "using 1:2", title, pltr.style)
+}
+
+// durationUnits maps the usual duration units to their symbol.
+var durationUnits = map[time.Duration]string{
+	time.Nanosecond:  "ns",
+	time.Microsecond: "us",
+	time.Millisecond: "ms",
+	time.Second:      "s",
+	time.Minute:      "min",
+	time.Hour:        "h",
+}
+
+// PlotXYDuration will create a 2-d plot of the durations `y` against `x`,
+// with `title` as the plot title. The durations are plotted as multiples of
+// `unit`, eg. time.Millisecond, which the y-axis label notes.
+// The data points are the pairs (x[i], y[i]) where `i` runs from 0 to the
+// smallest length of the 2 slices.
+// Example:
+//  err = p.PlotXYDuration(
+//           []float64{1, 2, 3},
+//           []time.Duration{120 * time.Millisecond, 95 * time.Millisecond, 210 * time.Millisecond},
+//           time.Millisecond,
+//           "latency")
+func (pltr *Plotter) PlotXYDuration(x []float64, y []time.Duration, unit time.Duration, title string) error {
+	if unit <= 0 {
+		return &gnuplotError{fmt.Sprintf("invalid duration unit '%v'", unit)}
+	}
+	npoints := min(len(x), len(y))
+	if npoints == 0 {
+		return errNoData
+	}
+	values := make([]float64, npoints)
+	for i := range values {
+		values[i] = float64(y[i]) / float64(unit)
+	}
+
+	symbol, ok := durationUnits[unit]
+	if !ok {
+		symbol = "x " + unit.String()
+	}
+	err := pltr.Cmd("set ylabel %s", quote("duration ("+symbol+")"))
+	if err != nil {
+		return err
+	}
+	return pltr.PlotXY(x[:npoints], values, title)
+}